@@ -0,0 +1,83 @@
+// Command createadmin bootstraps the first admin. It promotes an existing
+// user, or creates a new account when no user has the given email. The
+// password is read from ADMIN_PASSWORD so it stays out of shell history.
+//
+//	go run ./cmd/createadmin -email alice@example.com
+//	ADMIN_PASSWORD=... go run ./cmd/createadmin -email root@example.com -username root
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"devgraph/internal/auth"
+	"devgraph/internal/config"
+	"devgraph/internal/user"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the user to promote or create (required)")
+	username := flag.String("username", "", "username when creating a new user")
+	role := flag.String("role", user.RoleAdmin, "role to grant (user, moderator or admin)")
+	flag.Parse()
+
+	if *email == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !user.ValidRole(*role) {
+		log.Fatalf("unknown role %q", *role)
+	}
+
+	_ = godotenv.Load()
+
+	db, err := config.ConnectDatabase()
+	if err != nil {
+		log.Fatal("Database connection failed:", err)
+	}
+	if err := db.AutoMigrate(&user.User{}); err != nil {
+		log.Fatal("Migration failed:", err)
+	}
+
+	var u user.User
+	err = db.Where("email = ?", *email).First(&u).Error
+	switch {
+	case err == nil:
+		if err := db.Model(&u).Update("role", *role).Error; err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Granted %s to %s (%s)\n", *role, u.Username, u.ID)
+
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		password := os.Getenv("ADMIN_PASSWORD")
+		if *username == "" || len(password) < 8 {
+			log.Fatal("no user with that email; pass -username and set ADMIN_PASSWORD (at least 8 characters) to create one")
+		}
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		u = user.User{
+			ID:           uuid.New(),
+			Username:     *username,
+			Email:        *email,
+			PasswordHash: hash,
+			Role:         *role,
+			CreatedAt:    time.Now(),
+		}
+		if err := db.Create(&u).Error; err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Created %s %s (%s)\n", *role, u.Username, u.ID)
+
+	default:
+		log.Fatal(err)
+	}
+}
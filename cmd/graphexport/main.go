@@ -0,0 +1,60 @@
+// Command graphexport writes the active similarity graph to a file or
+// stdout for analysis in Gephi, Cytoscape or Graphviz.
+//
+//	go run ./cmd/graphexport -format gexf -min-similarity 0.3 -o devgraph.gexf
+//	go run ./cmd/graphexport -format dot -ego <user-id> -radius 2 | dot -Tsvg
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"devgraph/internal/config"
+	"devgraph/internal/graph"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	format := flag.String("format", graph.FormatGraphML, "output format ("+strings.Join(graph.ExportFormats(), ", ")+")")
+	out := flag.String("o", "", "output file (default stdout)")
+	minSimilarity := flag.Float64("min-similarity", 0, "drop edges below this similarity")
+	ego := flag.String("ego", "", "export only this user's ego network")
+	radius := flag.Int("radius", 1, "ego network radius in hops")
+	flag.Parse()
+
+	filter := graph.ExportFilter{MinSimilarity: *minSimilarity}
+	if *ego != "" {
+		id, err := uuid.Parse(*ego)
+		if err != nil {
+			log.Fatalf("invalid ego user id %q", *ego)
+		}
+		filter.Ego = &id
+		filter.Radius = *radius
+	}
+
+	_ = godotenv.Load()
+
+	db, err := config.ConnectDatabase()
+	if err != nil {
+		log.Fatal("Database connection failed:", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := graph.WriteGraph(db, w, *format, filter); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,12 +3,20 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"devgraph/internal/analysis"
 	"devgraph/internal/auth"
 	"devgraph/internal/code"
 	"devgraph/internal/config"
+	"devgraph/internal/connection"
+	"devgraph/internal/export"
+	"devgraph/internal/gitimport"
 	"devgraph/internal/graph"
+	"devgraph/internal/mail"
+	"devgraph/internal/plagiarism"
+	"devgraph/internal/problem"
+	"devgraph/internal/review"
 	"devgraph/internal/user"
 
 	"github.com/gin-contrib/cors"
@@ -30,11 +38,36 @@ func main() {
 	err = db.AutoMigrate(
 		&user.User{},
 		&auth.Session{},
+		&auth.RotatedRefreshToken{},
+		&auth.SecurityEvent{},
+		&auth.AccountToken{},
+		&problem.Problem{},
 		&code.CodeSubmission{},
+		&gitimport.RepoImport{},
 		&analysis.CodeAnalysis{},
 		&analysis.AlgorithmPattern{},
 		&analysis.SubmissionPattern{},
 		&graph.UserSimilarityEdge{}, // 👈 PHASE 7 TABLE
+		&graph.GraphVersion{},
+		&graph.Community{},
+		&graph.UserCommunity{},
+		&graph.BuildJob{},
+		&graph.UserCentrality{},
+		&graph.PatternActivity{},
+		&graph.PatternFrequency{},
+		&graph.PatternCooccurrence{},
+		&graph.PatternSimilarity{},
+		&graph.FeatureFrequency{},
+		&graph.GraphUserUpdate{},
+		&graph.RecommendationFeedback{},
+		&connection.ConnectionRequest{},
+		&connection.Follow{},
+		&connection.Block{},
+		&plagiarism.SubmissionFingerprint{},
+		&plagiarism.SubmissionMatch{},
+		&review.ReviewThread{},
+		&review.ReviewComment{},
+		&export.ExportJob{},
 	)
 
 	if err != nil {
@@ -42,8 +75,38 @@ func main() {
 	}
 	analysis.StartWorkerPool(db, 4)
 
+	// Incremental updates keep edges fresh; the periodic full rebuild is a
+	// consistency pass. GRAPH_REBUILD_INTERVAL=0 disables it.
+	rebuildInterval := time.Hour
+	if v := os.Getenv("GRAPH_REBUILD_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			rebuildInterval = d
+		}
+	}
+	if rebuildInterval > 0 {
+		graph.StartPeriodicRebuild(db, rebuildInterval)
+	}
+
+	// Expired sessions and export archives are purged hourly;
+	// SESSION_PURGE_INTERVAL=0 disables it.
+	purgeInterval := time.Hour
+	if v := os.Getenv("SESSION_PURGE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			purgeInterval = d
+		}
+	}
+	if purgeInterval > 0 {
+		auth.StartSessionPurge(db, purgeInterval)
+		export.StartExportPurge(db, purgeInterval)
+	}
 
 
+
+	mailer, err := mail.NewFromEnv()
+	if err != nil {
+		log.Fatal("Mail configuration failed:", err)
+	}
+
 	// Gin router
 	r := gin.Default()
 
@@ -62,14 +125,51 @@ func main() {
 	// Auth routes (public)
 	authGroup := r.Group("/auth")
 	{
-		authGroup.POST("/register", auth.Register(db))
+		authGroup.POST("/register", auth.Register(db, mailer))
 		authGroup.POST("/login", auth.Login(db))
 		authGroup.POST("/refresh", auth.Refresh(db))
 		authGroup.POST("/logout", auth.Logout(db))
+		authGroup.POST("/verify-email", auth.VerifyEmail(db))
+		authGroup.POST("/verify-email/resend", auth.ResendVerification(db, mailer))
+		authGroup.POST("/forgot-password", auth.ForgotPassword(db, mailer))
+		authGroup.POST("/reset-password", auth.ResetPassword(db))
 
 
 	}
 
+	// Export downloads are authorized by their one-time token
+	r.GET("/export/download/:token", export.Download(db))
+
+	// Administration: user roles and security events
+	admin := r.Group("/api/admin")
+	admin.Use(auth.JWTAuthMiddleware(), auth.RequireRole(user.RoleAdmin))
+	{
+		admin.GET("/users", user.ListUsers(db))
+		admin.PUT("/users/:id/role", user.SetRole(db))
+		admin.GET("/security-events", auth.ListSecurityEvents(db))
+	}
+
+	// Moderation: moderators and admins
+	moderation := r.Group("/api/moderation")
+	moderation.Use(auth.JWTAuthMiddleware(), auth.RequireRole(user.RoleModerator))
+	{
+		moderation.GET("/plagiarism/matches", plagiarism.ListAllMatches(db))
+	}
+
+	// Graph operations: admins or the scheduler token
+	operations := r.Group("/api")
+	operations.Use(auth.AdminOrSchedulerMiddleware())
+	{
+		operations.POST("/build-graph", graph.StartGraphBuild(db))
+		operations.POST("/graph/builds", graph.StartGraphBuild(db))
+		operations.GET("/graph/builds", graph.ListGraphBuilds(db))
+		operations.GET("/graph/builds/:id", graph.GetGraphBuild(db))
+		operations.POST("/graph/versions/:id/activate", graph.ActivateGraphVersion(db))
+		operations.GET("/recommendations/feedback/stats", graph.GetFeedbackStats(db))
+		operations.GET("/graph/connection-stats", graph.GetConnectionStats(db))
+		operations.GET("/graph/export", graph.ExportGraph(db))
+	}
+
 	// Protected routes
 	protected := r.Group("/api")
 	protected.Use(auth.JWTAuthMiddleware())
@@ -77,11 +177,58 @@ func main() {
 		protected.GET("/me", user.GetProfile(db))
 		protected.GET("/profile", user.GetProfile(db))
 		protected.PUT("/profile", user.UpdateProfile(db))
+		protected.POST("/password", auth.ChangePassword(db))
+		protected.GET("/sessions", auth.ListSessions(db))
+		protected.DELETE("/sessions", auth.RevokeOtherSessions(db))
+		protected.DELETE("/sessions/:id", auth.RevokeSession(db))
 		protected.POST("/submit", code.SubmitCode(db))
 		protected.GET("/submissions", analysis.GetUserSubmissions(db))
 		protected.GET("/analysis/:id", analysis.GetAnalysis(db))
 		protected.GET("/recommendations", graph.GetRecommendations(db))
-		protected.POST("/build-graph", graph.BuildGraph(db))
+		protected.GET("/recommendations/mentors", graph.GetMentorRecommendations(db))
+		protected.GET("/recommendations/patterns", graph.GetPatternRecommendations(db))
+		protected.POST("/recommendations/:user_id/accept", graph.AcceptRecommendation(db))
+		protected.POST("/recommendations/:user_id/dismiss", graph.DismissRecommendation(db))
+		protected.GET("/graph/versions", graph.ListVersions(db))
+		protected.GET("/graph/neighbourhood", graph.GetNeighbourhood(db))
+		protected.GET("/graph/ego", graph.GetEgoNetwork(db))
+		protected.GET("/graph/path", graph.GetStrongestPath(db))
+		protected.GET("/graph/patterns", graph.GetPatternGraph(db))
+		protected.GET("/communities", graph.ListCommunities(db))
+		protected.GET("/communities/:id/members", graph.GetCommunityMembers(db))
+		protected.GET("/community", graph.GetUserCommunity(db))
+		protected.GET("/users/:id/community", graph.GetUserCommunity(db))
+		protected.GET("/leaderboards/influence", graph.GetInfluenceLeaderboard(db))
+		protected.GET("/leaderboards/patterns", graph.ListPatternLeaderboards(db))
+		protected.GET("/leaderboards/patterns/:pattern", graph.GetPatternLeaderboard(db))
+		protected.GET("/connections", connection.ListConnections(db))
+		protected.DELETE("/connections/:user_id", connection.RemoveConnection(db))
+		protected.POST("/connections/requests", connection.SendRequest(db))
+		protected.GET("/connections/requests", connection.ListRequests(db))
+		protected.DELETE("/connections/requests/:id", connection.CancelRequest(db))
+		protected.POST("/connections/requests/:id/accept", connection.AcceptRequest(db))
+		protected.POST("/connections/requests/:id/decline", connection.DeclineRequest(db))
+		protected.POST("/follows/:user_id", connection.FollowUser(db))
+		protected.DELETE("/follows/:user_id", connection.UnfollowUser(db))
+		protected.GET("/users/:id/followers", connection.ListFollowers(db))
+		protected.GET("/users/:id/following", connection.ListFollowing(db))
+		protected.GET("/blocks", connection.ListBlocks(db))
+		protected.POST("/blocks/:user_id", connection.BlockUser(db))
+		protected.DELETE("/blocks/:user_id", connection.UnblockUser(db))
+		protected.GET("/plagiarism/matches", plagiarism.GetMyMatches(db))
+		protected.GET("/submissions/:id/matches", plagiarism.GetSubmissionMatches(db))
+		protected.POST("/problems", problem.CreateProblem(db))
+		protected.GET("/problems", problem.ListProblems(db))
+		protected.GET("/problems/:id", problem.GetProblem(db))
+		protected.GET("/problems/:id/approaches", problem.GetApproaches(db))
+		protected.POST("/import/git", gitimport.ImportGit(db))
+		protected.GET("/import/git", gitimport.GetImports(db))
+		protected.GET("/submissions/:id/reviews", review.GetThreads(db))
+		protected.POST("/submissions/:id/reviews", review.CreateThread(db))
+		protected.POST("/reviews/:thread_id/comments", review.AddComment(db))
+		protected.PATCH("/reviews/:thread_id", review.ResolveThread(db))
+		protected.POST("/export", export.StartExport(db))
+		protected.GET("/export/:id", export.GetExport(db))
 
 
 	}
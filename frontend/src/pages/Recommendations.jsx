@@ -2,11 +2,9 @@ import { Code2, Info, Star, TrendingUp, Users, X, Sparkles, Zap } from 'lucide-r
 import { useEffect, useState } from 'react';
 import toast from 'react-hot-toast';
 import Navbar from '../components/Navbar';
-import { useAuth } from '../context/AuthContext';
 import { graphAPI } from '../services/api';
 
 export default function Recommendations() {
-  const { user } = useAuth();
   const [recommendations, setRecommendations] = useState([]);
   const [loading, setLoading] = useState(true);
   const [building, setBuilding] = useState(false);
@@ -20,7 +18,7 @@ export default function Recommendations() {
   const fetchRecommendations = async () => {
     try {
       const response = await graphAPI.getRecommendations();
-      setRecommendations(response.data || []);
+      setRecommendations(response.data?.recommendations || []);
       setError(null);
     } catch (error) {
       console.error('Recommendations error:', error);
@@ -34,13 +32,31 @@ export default function Recommendations() {
   const buildGraph = async () => {
     setBuilding(true);
     try {
-      await graphAPI.buildGraph();
+      const { data } = await graphAPI.buildGraph();
+      toast('Graph build started...');
+
+      // Builds run in the background; poll until this one finishes.
+      let job = data;
+      while (job.status === 'pending' || job.status === 'running') {
+        await new Promise((resolve) => setTimeout(resolve, 2000));
+        job = (await graphAPI.getBuild(data.job_id)).data;
+      }
+      if (job.status === 'failed') {
+        throw new Error(job.error || 'build failed');
+      }
+
       toast.success('Graph built! Refreshing recommendations...');
       setLoading(true);
       await fetchRecommendations();
     } catch (error) {
       console.error('Build graph error:', error);
-      toast.error('Failed to build graph');
+      if (error.response?.status === 403) {
+        toast.error('Only admins can rebuild the graph');
+      } else if (error.response?.status === 409) {
+        toast.error('A graph build is already running');
+      } else {
+        toast.error('Failed to build graph');
+      }
     } finally {
       setBuilding(false);
     }
@@ -195,7 +211,7 @@ export default function Recommendations() {
         ) : (
           <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-6">
             {recommendations.map((rec) => {
-              const otherUserId = rec.user_a === user?.id ? rec.user_b : rec.user_a;
+              const otherUser = rec.user || {};
               const sharedPatterns = rec.shared_patterns || [];
               
               return (
@@ -207,12 +223,12 @@ export default function Recommendations() {
                   <div className="text-center">
                     <div className="w-24 h-24 bg-gradient-to-br from-purple-500 to-cyan-600 rounded-full flex items-center justify-center mx-auto mb-4 shadow-lg">
                       <span className="text-3xl font-bold text-white">
-                        {String(otherUserId || '').substring(0, 2).toUpperCase()}
+                        {String(otherUser.username || otherUser.id || '').substring(0, 2).toUpperCase()}
                       </span>
                     </div>
                     
                     <h3 className="text-lg font-semibold text-white mb-2">
-                      Developer #{String(otherUserId || '').substring(0, 8)}
+                      {otherUser.username || `Developer #${String(otherUser.id || '').substring(0, 8)}`}
                     </h3>
                     
                     <div className="flex items-center justify-center gap-2 mb-4">
@@ -359,7 +375,7 @@ export default function Recommendations() {
               )}
 
               {/* Your Patterns & Their Patterns */}
-              {selectedRec.user_a_patterns && selectedRec.user_b_patterns && (
+              {selectedRec.your_patterns && selectedRec.their_patterns && (
                 <>
                   <div className="bg-blue-500/10 rounded-2xl p-5 border border-blue-500/30">
                     <div className="flex items-center gap-2 mb-3">
@@ -367,7 +383,7 @@ export default function Recommendations() {
                       <h3 className="font-bold text-blue-300">Your Patterns</h3>
                     </div>
                     <div className="flex flex-wrap gap-2">
-                      {(selectedRec.user_a === user?.id ? selectedRec.user_a_patterns : selectedRec.user_b_patterns).map((pattern, idx) => (
+                      {selectedRec.your_patterns.map((pattern, idx) => (
                         <span key={idx} className="px-3 py-1.5 bg-blue-500/20 text-blue-300 rounded-lg text-sm border border-blue-500/30">
                           {pattern}
                         </span>
@@ -381,7 +397,7 @@ export default function Recommendations() {
                       <h3 className="font-bold text-purple-300">Their Patterns</h3>
                     </div>
                     <div className="flex flex-wrap gap-2">
-                      {(selectedRec.user_a === user?.id ? selectedRec.user_b_patterns : selectedRec.user_a_patterns).map((pattern, idx) => (
+                      {selectedRec.their_patterns.map((pattern, idx) => (
                         <span key={idx} className="px-3 py-1.5 bg-purple-500/20 text-purple-300 rounded-lg text-sm border border-purple-500/30">
                           {pattern}
                         </span>
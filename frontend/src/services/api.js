@@ -65,6 +65,12 @@ export const authAPI = {
   login: (data) => api.post('/auth/login', data),
   logout: () => api.post('/auth/logout'),
   getMe: () => api.get('/api/me'),
+  verifyEmail: (token) => api.post('/auth/verify-email', { token }),
+  resendVerification: (email) => api.post('/auth/verify-email/resend', { email }),
+  forgotPassword: (email) => api.post('/auth/forgot-password', { email }),
+  resetPassword: (token, password) => api.post('/auth/reset-password', { token, password }),
+  changePassword: (currentPassword, newPassword) =>
+    api.post('/api/password', { current_password: currentPassword, new_password: newPassword }),
 };
 
 export const profileAPI = {
@@ -80,7 +86,25 @@ export const codeAPI = {
 
 export const graphAPI = {
   getRecommendations: () => api.get('/api/recommendations'),
-  buildGraph: () => api.post('/api/build-graph'),
+  acceptRecommendation: (userId) => api.post(`/api/recommendations/${userId}/accept`),
+  dismissRecommendation: (userId) => api.post(`/api/recommendations/${userId}/dismiss`),
+  buildGraph: () => api.post('/api/graph/builds'),
+  getBuild: (jobId) => api.get(`/api/graph/builds/${jobId}`),
+  getPatternGraph: (params) => api.get('/api/graph/patterns', { params }),
+};
+
+export const connectionAPI = {
+  getConnections: () => api.get('/api/connections'),
+  removeConnection: (userId) => api.delete(`/api/connections/${userId}`),
+  sendRequest: (userId) => api.post('/api/connections/requests', { user_id: userId }),
+  getRequests: (direction = 'incoming') => api.get('/api/connections/requests', { params: { direction } }),
+  acceptRequest: (requestId) => api.post(`/api/connections/requests/${requestId}/accept`),
+  declineRequest: (requestId) => api.post(`/api/connections/requests/${requestId}/decline`),
+  cancelRequest: (requestId) => api.delete(`/api/connections/requests/${requestId}`),
+  follow: (userId) => api.post(`/api/follows/${userId}`),
+  unfollow: (userId) => api.delete(`/api/follows/${userId}`),
+  block: (userId) => api.post(`/api/blocks/${userId}`),
+  unblock: (userId) => api.delete(`/api/blocks/${userId}`),
 };
 
 export default api;
\ No newline at end of file
@@ -2,7 +2,11 @@ package analysis
 
 import "github.com/google/uuid"
 
+// SubmissionPattern links a submission to a detected pattern. Confidence is
+// the detector's certainty in [0, 1]; the structural detector makes binary
+// decisions and records 1.
 type SubmissionPattern struct {
 	SubmissionID uuid.UUID `gorm:"type:uuid;primaryKey"`
 	PatternID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Confidence   float64   `gorm:"not null;default:1"`
 }
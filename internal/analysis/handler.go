@@ -3,6 +3,8 @@ package analysis
 import (
 	"net/http"
 
+	"devgraph/internal/review"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -16,12 +18,29 @@ type AnalysisResponse struct {
 	Issues          string    `json:"issues"`
 	Patterns        []string  `json:"patterns"`
 	CreatedAt       string    `json:"created_at"`
+
+	ReviewThreads []review.ThreadResponse `json:"review_threads"`
 }
 
 func GetAnalysis(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
 		submissionID := c.Param("id")
 
+		// Private submissions are only visible to their owner
+		var owner struct {
+			UserID     uuid.UUID
+			Visibility string
+		}
+		if err := db.Table("code_submissions").
+			Select("user_id, visibility").
+			Where("id = ?", submissionID).
+			Take(&owner).Error; err != nil ||
+			(owner.Visibility == "private" && owner.UserID != userID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "analysis not found"})
+			return
+		}
+
 		var analysis CodeAnalysis
 		if err := db.Where("submission_id = ?", submissionID).First(&analysis).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "analysis not found"})
@@ -51,6 +70,13 @@ func GetAnalysis(db *gorm.DB) gin.HandlerFunc {
 			CreatedAt:       analysis.CreatedAt.Format("2006-01-02 15:04:05"),
 		}
 
+		threads, err := review.ThreadsForSubmission(db, analysis.SubmissionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load review threads"})
+			return
+		}
+		response.ReviewThreads = threads
+
 		c.JSON(http.StatusOK, response)
 	}
 }
@@ -61,14 +87,16 @@ func GetUserSubmissions(db *gorm.DB) gin.HandlerFunc {
 		userID := c.MustGet("user_id").(uuid.UUID)
 
 		var submissions []struct {
-			ID         uuid.UUID `json:"id"`
-			Language   string    `json:"language"`
-			SourceCode string    `json:"source_code"`
-			CreatedAt  string    `json:"created_at"`
+			ID         uuid.UUID  `json:"id"`
+			Language   string     `json:"language"`
+			SourceCode string     `json:"source_code"`
+			ProblemID  *uuid.UUID `json:"problem_id"`
+			Visibility string     `json:"visibility"`
+			CreatedAt  string     `json:"created_at"`
 		}
 
 		db.Raw(`
-			SELECT id, language, source_code, created_at
+			SELECT id, language, source_code, problem_id, visibility, created_at
 			FROM code_submissions
 			WHERE user_id = ?
 			ORDER BY created_at DESC
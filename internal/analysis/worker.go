@@ -5,6 +5,9 @@ import (
 	//"strings"
 	"time"
 
+	"devgraph/internal/graph"
+	"devgraph/internal/plagiarism"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -28,11 +31,12 @@ func worker(db *gorm.DB, id int) {
 func analyzeSubmission(db *gorm.DB, submissionID interface{}) {
 	var submission struct {
 		ID         uuid.UUID
+		UserID     uuid.UUID
 		SourceCode string
 	}
 
 	if err := db.Table("code_submissions").
-		Select("id, source_code").
+		Select("id, user_id, source_code").
 		Where("id = ?", submissionID).
 		Scan(&submission).Error; err != nil {
 		log.Println("failed to load submission:", err)
@@ -80,10 +84,19 @@ func analyzeSubmission(db *gorm.DB, submissionID interface{}) {
 		SubmissionPattern{
 			SubmissionID: submission.ID,
 			PatternID:    pattern.ID,
+			Confidence:   1,
 		},
 	)
 
 	}
 
 	log.Printf("analysis stored for submission %s\n", submission.ID)
+
+	if err := plagiarism.CheckSubmission(db, submission.ID); err != nil {
+		log.Println("plagiarism check failed:", err)
+	}
+
+	if err := graph.UpdateUserGraph(db, submission.UserID); err != nil {
+		log.Println("incremental graph update failed:", err)
+	}
 }
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"devgraph/internal/mail"
+	"devgraph/internal/user"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// appURL is the frontend base URL used in mailed links (APP_URL).
+func appURL() string {
+	if v := os.Getenv("APP_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "http://localhost:3000"
+}
+
+// sendVerification mails u a link to verify their email address. Failures
+// are logged; the user can ask for a new link. Handlers run it in the
+// background so response times do not reveal whether an address is
+// registered.
+func sendVerification(db *gorm.DB, mailer mail.Mailer, u user.User) {
+	token, err := issueAccountToken(db, u.ID, PurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		log.Println("failed to issue verification token:", err)
+		return
+	}
+
+	err = mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Verify your DevGraph email address",
+		Body: "Hi " + u.Username + ",\n\n" +
+			"Confirm your email address by opening this link:\n\n" +
+			appURL() + "/verify-email?token=" + token + "\n\n" +
+			"The link expires in 48 hours. If you did not create an account, ignore this email.\n",
+	})
+	if err != nil {
+		log.Println("failed to send verification email:", err)
+	}
+}
+
+// VerifyEmail handles POST /auth/verify-email.
+func VerifyEmail(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req TokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			t, err := consumeAccountToken(tx, req.Token, PurposeVerifyEmail)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&user.User{}).Where("id = ?", t.UserID).Update("email_verified", true).Error
+		})
+		if errors.Is(err, errInvalidAccountToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+	}
+}
+
+// ResendVerification handles POST /auth/verify-email/resend.
+// The response is the same whether or not the address is registered.
+func ResendVerification(db *gorm.DB, mailer mail.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var u user.User
+		if err := db.Where("email = ?", req.Email).First(&u).Error; err == nil && !u.EmailVerified {
+			go sendVerification(db, mailer, u)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "if the address needs verification, a new link has been sent"})
+	}
+}
+
+// ForgotPassword handles POST /auth/forgot-password.
+// The response is the same whether or not the address is registered.
+func ForgotPassword(db *gorm.DB, mailer mail.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var u user.User
+		if err := db.Where("email = ?", req.Email).First(&u).Error; err == nil {
+			go sendPasswordReset(db, mailer, u)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "if the address is registered, a reset link has been sent"})
+	}
+}
+
+// sendPasswordReset mails u a single-use password reset link. Like
+// sendVerification it runs in the background and only logs failures.
+func sendPasswordReset(db *gorm.DB, mailer mail.Mailer, u user.User) {
+	token, err := issueAccountToken(db, u.ID, PurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		log.Println("failed to issue password reset token:", err)
+		return
+	}
+
+	err = mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Reset your DevGraph password",
+		Body: "Hi " + u.Username + ",\n\n" +
+			"Choose a new password by opening this link:\n\n" +
+			appURL() + "/reset-password?token=" + token + "\n\n" +
+			"The link expires in 1 hour and can be used once. If you did not ask for a reset, ignore this email.\n",
+	})
+	if err != nil {
+		log.Println("failed to send password reset email:", err)
+	}
+}
+
+// ResetPassword handles POST /auth/reset-password.
+// Sets a new password from a reset token and signs the user out everywhere.
+// The reset link proves control of the mailbox, so the email is marked
+// verified as well.
+func ResetPassword(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ResetPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		hash, err := HashPassword(req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			return
+		}
+
+		var userID uuid.UUID
+		err = db.Transaction(func(tx *gorm.DB) error {
+			t, err := consumeAccountToken(tx, req.Token, PurposeResetPassword)
+			if err != nil {
+				return err
+			}
+			userID = t.UserID
+			return tx.Model(&user.User{}).Where("id = ?", t.UserID).Updates(map[string]interface{}{
+				"password_hash":  hash,
+				"email_verified": true,
+			}).Error
+		})
+		if errors.Is(err, errInvalidAccountToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+			return
+		}
+
+		if err := revokeUserSessions(db, userID, uuid.Nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password reset, but failed to sign out other sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password reset; sign in with the new password"})
+	}
+}
+
+// ChangePassword handles POST /api/password.
+// Requires the current password and revokes every other session.
+func ChangePassword(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var req ChangePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var u user.User
+		if err := db.Where("id = ?", userID).First(&u).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if !CheckPasswordHash(req.CurrentPassword, u.PasswordHash) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+			return
+		}
+
+		hash, err := HashPassword(req.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			return
+		}
+		if err := db.Model(&u).Update("password_hash", hash).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+			return
+		}
+
+		current, _ := c.Get("session_id")
+		keep, _ := current.(uuid.UUID)
+		if err := revokeUserSessions(db, userID, keep); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password changed, but failed to sign out other sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+	}
+}
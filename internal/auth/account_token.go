@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+var errInvalidAccountToken = errors.New("invalid or expired token")
+
+// AccountToken is a single-use token mailed to a user to verify their
+// email address or reset their password. Only its SHA-256 hash is stored.
+type AccountToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Purpose   string    `gorm:"not null"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// issueAccountToken creates a token for purpose and returns it in plain
+// text. Earlier unused tokens of the same purpose stop working, so only the
+// most recently mailed link is valid.
+func issueAccountToken(db *gorm.DB, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	// Same format as refresh tokens: 32 random bytes, hex encoded.
+	token, err := GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Delete(&AccountToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&AccountToken{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: HashRefreshToken(token),
+			ExpiresAt: now.Add(ttl),
+			CreatedAt: now,
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeAccountToken marks a valid token as used and returns it. It must
+// run inside the transaction that applies the token's effect, so a failed
+// change leaves the token usable.
+func consumeAccountToken(tx *gorm.DB, token, purpose string) (*AccountToken, error) {
+	var t AccountToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", HashRefreshToken(token), purpose).
+		Take(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errInvalidAccountToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if t.UsedAt != nil || now.After(t.ExpiresAt) {
+		return nil, errInvalidAccountToken
+	}
+
+	t.UsedAt = &now
+	if err := tx.Model(&t).Update("used_at", now).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
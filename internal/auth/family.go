@@ -0,0 +1,310 @@
+package auth
+
+// family.go — refresh-token rotation with reuse detection
+//
+// A session is a refresh-token family: every refresh replaces the
+// session's token and keeps the hash of the old one as a
+// RotatedRefreshToken. A legitimate client only ever holds the newest
+// token, so presenting a rotated one means it was copied; the whole family
+// is revoked and a SecurityEvent is recorded.
+//
+// The one exception is a client that sends the same token twice, e.g. from
+// two tabs or after losing the first response. Successors are derived from
+// the token they replace (see deriveRefreshToken), so a token rotated less
+// than refreshGraceWindow ago, whose successor the session still holds, is
+// answered with that same successor instead of counting as reuse.
+//
+// Rotation updates Postgres in one transaction with the session row locked,
+// so two concurrent refreshes with the same token cannot both rotate it,
+// and loads the owner's role and signs the access token before committing,
+// so a committed rotation always reaches the client. It then swaps the
+// session: Redis keys in one MULTI/EXEC, so Redis only ever holds the live
+// token of each session. Postgres stays authoritative: a token is accepted
+// only while its session row holds it.
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"devgraph/internal/cache"
+	"devgraph/internal/user"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	refreshTokenTTL = 7 * 24 * time.Hour
+
+	// refreshGraceWindow is how long a rotated token may be presented again
+	// to receive the same successor.
+	refreshGraceWindow = 10 * time.Second
+
+	EventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+var (
+	errInvalidRefresh = errors.New("invalid refresh token")
+	errExpiredRefresh = errors.New("refresh token expired")
+	errReusedRefresh  = errors.New("refresh token reuse detected")
+)
+
+// RotatedRefreshToken remembers a replaced refresh token of a session until
+// the time it would have expired.
+type RotatedRefreshToken struct {
+	Hash      string    `gorm:"primaryKey"`
+	SessionID uuid.UUID `gorm:"type:uuid;not null;index"`
+	RotatedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// SecurityEvent records suspicious activity on an account.
+type SecurityEvent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	SessionID uuid.UUID `gorm:"type:uuid"`
+	Type      string    `gorm:"not null;index"`
+	IP        string    `gorm:"column:ip;default:''"`
+	UserAgent string    `gorm:"type:text;default:''"`
+	CreatedAt time.Time
+}
+
+func sessionKey(hash string) string {
+	return "session:" + hash
+}
+
+// refreshOutcome is what rotateRefreshToken does with a presented token.
+type refreshOutcome int
+
+const (
+	refreshInvalid refreshOutcome = iota
+	refreshExpired
+	refreshRotate
+	refreshGrace
+	refreshReuse
+)
+
+// classifyRefresh decides the outcome for a presented token. live is the
+// session currently holding it; otherwise rotated is its rotation record,
+// family the session it was rotated in (nil once revoked) and successor the
+// hash of the token it was rotated to.
+func classifyRefresh(live *Session, rotated *RotatedRefreshToken, family *Session, successor string, now time.Time) refreshOutcome {
+	switch {
+	case live != nil && now.After(live.ExpiresAt):
+		return refreshExpired
+	case live != nil:
+		return refreshRotate
+	case rotated == nil:
+		return refreshInvalid
+	case family != nil &&
+		family.RefreshTokenHash == successor &&
+		now.Sub(rotated.RotatedAt) <= refreshGraceWindow &&
+		!now.After(family.ExpiresAt):
+		return refreshGrace
+	}
+	return refreshReuse
+}
+
+// rotateRefreshToken exchanges a refresh token for its successor. It
+// returns the new refresh token and an access token carrying
+// the owner's current role, or errReusedRefresh after revoking the family
+// when the token had already been rotated outside the grace window.
+func rotateRefreshToken(c *gin.Context, db *gorm.DB, token string) (string, string, error) {
+	hashed := HashRefreshToken(token)
+
+	var session Session
+	var reused *RotatedRefreshToken
+	var newRefresh, accessToken string
+	grace := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var live, family *Session
+		var rotated *RotatedRefreshToken
+
+		var held Session
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token_hash = ?", hashed).
+			Take(&held).Error
+		switch {
+		case err == nil:
+			live = &held
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		default:
+			var r RotatedRefreshToken
+			err := tx.Where("hash = ?", hashed).Take(&r).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				rotated = &r
+				var f Session
+				err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+					Where("id = ?", r.SessionID).
+					Take(&f).Error
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if err == nil {
+					family = &f
+				}
+			}
+		}
+
+		successor := ""
+		if rotated != nil {
+			successor = HashRefreshToken(deriveRefreshToken(rotated.SessionID, token))
+		}
+
+		now := time.Now()
+		switch classifyRefresh(live, rotated, family, successor, now) {
+		case refreshInvalid:
+			return errInvalidRefresh
+		case refreshExpired:
+			return errExpiredRefresh
+		case refreshReuse:
+			reused = rotated
+			return errReusedRefresh
+		case refreshGrace:
+			session = *family
+			newRefresh = deriveRefreshToken(family.ID, token)
+			grace = true
+		case refreshRotate:
+			session = *live
+			newRefresh = deriveRefreshToken(session.ID, token)
+			if err := tx.Create(&RotatedRefreshToken{
+				Hash:      hashed,
+				SessionID: session.ID,
+				RotatedAt: now,
+				ExpiresAt: session.ExpiresAt,
+			}).Error; err != nil {
+				return err
+			}
+
+			session.RefreshTokenHash = HashRefreshToken(newRefresh)
+			session.ExpiresAt = now.Add(refreshTokenTTL)
+			session.LastUsedAt = now
+			session.UserAgent = c.Request.UserAgent()
+			session.IP = c.ClientIP()
+			if err := tx.Save(&session).Error; err != nil {
+				return err
+			}
+		}
+
+		// The new access token carries the user's current role
+		var owner user.User
+		err = tx.Select("id, role").Where("id = ?", session.UserID).Take(&owner).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidRefresh
+		}
+		if err != nil {
+			return err
+		}
+		accessToken, err = GenerateAccessToken(owner.ID, owner.Role, session.ID)
+		return err
+	})
+	if errors.Is(err, errReusedRefresh) {
+		revokeFamily(c, db, reused.SessionID)
+		return "", "", err
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if grace {
+		return newRefresh, accessToken, nil
+	}
+
+	// The rotation is committed, so the new token is valid even if the
+	// cache cannot be updated; failing here would strand the client.
+	redisClient := cache.NewRedisClient()
+	_, err = redisClient.TxPipelined(cache.Ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(cache.Ctx, sessionKey(hashed))
+		pipe.Set(cache.Ctx, sessionKey(session.RefreshTokenHash), session.UserID.String(), refreshTokenTTL)
+		return nil
+	})
+	if err != nil {
+		log.Println("failed to update rotated session in redis:", err)
+	}
+
+	return newRefresh, accessToken, nil
+}
+
+// revokeFamily revokes the session a reused token belonged to and records
+// the security event. The session may already be gone, e.g. after logout.
+func revokeFamily(c *gin.Context, db *gorm.DB, sessionID uuid.UUID) {
+	var sessions []Session
+	if err := db.Where("id = ?", sessionID).Find(&sessions).Error; err != nil {
+		log.Println("failed to load reused session family:", err)
+		return
+	}
+	if len(sessions) == 0 {
+		return
+	}
+	if err := revokeSessions(db, sessions); err != nil {
+		log.Println("failed to revoke reused session family:", err)
+	}
+
+	event := SecurityEvent{
+		ID:        uuid.New(),
+		UserID:    sessions[0].UserID,
+		SessionID: sessionID,
+		Type:      EventRefreshTokenReuse,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		CreatedAt: time.Now(),
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Println("failed to record security event:", err)
+	}
+	log.Printf("SECURITY: refresh token reuse for user %s, session %s revoked (ip=%s)\n",
+		event.UserID, sessionID, event.IP)
+}
+
+type SecurityEventResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListSecurityEvents handles GET /api/admin/security-events.
+// Supports ?user_id= and ?limit= (default 50, at most 200); newest first.
+func ListSecurityEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+
+		query := db.Order("created_at DESC").Limit(limit)
+		if v := c.Query("user_id"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+				return
+			}
+			query = query.Where("user_id = ?", id)
+		}
+
+		var events []SecurityEvent
+		if err := query.Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load security events"})
+			return
+		}
+
+		out := make([]SecurityEventResponse, 0, len(events))
+		for _, e := range events {
+			out = append(out, SecurityEventResponse(e))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
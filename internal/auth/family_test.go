@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClassifyRefresh(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	successor := HashRefreshToken("successor")
+
+	live := &Session{RefreshTokenHash: HashRefreshToken("token"), ExpiresAt: now.Add(time.Hour)}
+	expired := &Session{RefreshTokenHash: HashRefreshToken("token"), ExpiresAt: now.Add(-time.Second)}
+	rotatedAt := func(ago time.Duration) *RotatedRefreshToken {
+		return &RotatedRefreshToken{RotatedAt: now.Add(-ago), ExpiresAt: now.Add(time.Hour)}
+	}
+	holding := func(hash string) *Session {
+		return &Session{RefreshTokenHash: hash, ExpiresAt: now.Add(time.Hour)}
+	}
+
+	tests := []struct {
+		name    string
+		live    *Session
+		rotated *RotatedRefreshToken
+		family  *Session
+		want    refreshOutcome
+	}{
+		{"unknown token", nil, nil, nil, refreshInvalid},
+		{"live token", live, nil, nil, refreshRotate},
+		{"expired session", expired, nil, nil, refreshExpired},
+		{"retry within grace window", nil, rotatedAt(time.Second), holding(successor), refreshGrace},
+		{"retry at end of grace window", nil, rotatedAt(refreshGraceWindow), holding(successor), refreshGrace},
+		{"reuse after grace window", nil, rotatedAt(refreshGraceWindow + time.Second), holding(successor), refreshReuse},
+		{"successor already rotated", nil, rotatedAt(time.Second), holding(HashRefreshToken("later")), refreshReuse},
+		{"family revoked", nil, rotatedAt(time.Second), nil, refreshReuse},
+		{"family expired", nil, rotatedAt(time.Second), &Session{RefreshTokenHash: successor, ExpiresAt: now.Add(-time.Second)}, refreshReuse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyRefresh(tt.live, tt.rotated, tt.family, successor, now); got != tt.want {
+				t.Errorf("classifyRefresh = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveRefreshToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	session := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	other := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+	token := deriveRefreshToken(session, "token")
+	if token != deriveRefreshToken(session, "token") {
+		t.Error("successor is not deterministic")
+	}
+	if token == deriveRefreshToken(other, "token") {
+		t.Error("successor does not depend on the session")
+	}
+	if token == deriveRefreshToken(session, "other") {
+		t.Error("successor does not depend on the token")
+	}
+
+	t.Setenv("JWT_SECRET", "rotated")
+	if token == deriveRefreshToken(session, "token") {
+		t.Error("successor does not depend on the secret")
+	}
+}
@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"devgraph/internal/cache"
+	"devgraph/internal/mail"
 	"devgraph/internal/user"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +14,9 @@ import (
 	"gorm.io/gorm"
 )
 
-func Register(db *gorm.DB) gin.HandlerFunc {
+// Register handles POST /auth/register and mails a verification link to the
+// new address.
+func Register(db *gorm.DB, mailer mail.Mailer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req RegisterRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -39,6 +43,8 @@ func Register(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		go sendVerification(db, mailer, newUser)
+
 		c.JSON(http.StatusCreated, gin.H{
 			"message": "user registered successfully",
 			"user_id": newUser.ID,
@@ -65,13 +71,6 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Generate access token
-		accessToken, err := GenerateAccessToken(existingUser.ID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
-			return
-		}
-
 		// Generate refresh token
 		refreshToken, err := GenerateRefreshToken()
 		if err != nil {
@@ -81,12 +80,22 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 
 		hashedRefresh := HashRefreshToken(refreshToken)
 
+		label := req.DeviceLabel
+		if label == "" {
+			label = deviceLabel(c.Request.UserAgent())
+		}
+
+		now := time.Now()
 		session := Session{
 			ID:               uuid.New(),
 			UserID:           existingUser.ID,
 			RefreshTokenHash: hashedRefresh,
-			ExpiresAt:        time.Now().Add(7 * 24 * time.Hour),
-			CreatedAt:        time.Now(),
+			DeviceLabel:      label,
+			UserAgent:        c.Request.UserAgent(),
+			IP:               c.ClientIP(),
+			ExpiresAt:        now.Add(refreshTokenTTL),
+			LastUsedAt:       now,
+			CreatedAt:        now,
 		}
 
 		// Store session in DB
@@ -99,7 +108,7 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 		redisClient := cache.NewRedisClient()
 		err = redisClient.Set(
 			cache.Ctx,
-			"session:"+hashedRefresh,
+			sessionKey(hashedRefresh),
 			session.UserID.String(),
 			time.Until(session.ExpiresAt),
 		).Err()
@@ -109,6 +118,13 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// Generate access token
+		accessToken, err := GenerateAccessToken(existingUser.ID, existingUser.Role, session.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"access_token":  accessToken,
 			"refresh_token": refreshToken,
@@ -116,6 +132,9 @@ func Login(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// Refresh handles POST /auth/refresh. It rotates the refresh token (see
+// family.go); presenting an already rotated token revokes its session
+// unless it was rotated within the grace window.
 func Refresh(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req RefreshRequest
@@ -124,49 +143,19 @@ func Refresh(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		hashed := HashRefreshToken(req.RefreshToken)
-
-		redisClient := cache.NewRedisClient()
-
-		_, err := redisClient.Get(cache.Ctx, "session:"+hashed).Result()
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		newRefresh, accessToken, err := rotateRefreshToken(c, db, req.RefreshToken)
+		switch {
+		case errors.Is(err, errExpiredRefresh):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
 			return
-		}
-
-		var session Session
-		if err := db.Where("refresh_token_hash = ?", hashed).First(&session).Error; err != nil {
+		case errors.Is(err, errInvalidRefresh), errors.Is(err, errReusedRefresh):
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
 			return
-		}
-
-		if time.Now().After(session.ExpiresAt) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
-			return
-		}
-
-		// Rotate refresh token
-		newRefresh, err := GenerateRefreshToken()
-		if err != nil {
+		case err != nil:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate refresh token"})
 			return
 		}
 
-		session.RefreshTokenHash = HashRefreshToken(newRefresh)
-		session.ExpiresAt = time.Now().Add(7 * 24 * time.Hour)
-
-		if err := db.Save(&session).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update session"})
-			return
-		}
-
-		// New access token
-		accessToken, err := GenerateAccessToken(session.UserID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
-			return
-		}
-
 		c.JSON(http.StatusOK, gin.H{
 			"access_token":  accessToken,
 			"refresh_token": newRefresh,
@@ -182,14 +171,16 @@ func Logout(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		hashed := HashRefreshToken(req.RefreshToken)
-
-		// Delete from Redis
-		redisClient := cache.NewRedisClient()
-		redisClient.Del(cache.Ctx, "session:"+hashed)
-
-		// Delete from DB
-		db.Where("refresh_token_hash = ?", hashed).Delete(&Session{})
+		// Revoke the session with its Redis key and rotated tokens
+		var sessions []Session
+		if err := db.Where("refresh_token_hash = ?", HashRefreshToken(req.RefreshToken)).Find(&sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load session"})
+			return
+		}
+		if err := revokeSessions(db, sessions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 	}
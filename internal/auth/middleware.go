@@ -1,49 +1,110 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"os"
 	"strings"
 
+	"devgraph/internal/user"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+		claims, ok := authenticate(c)
+		if !ok {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
-			return
-		}
+		// Attach user ID, role and session to request context
+		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 
-		tokenStr := parts[1]
-		secret := os.Getenv("JWT_SECRET")
+		c.Next()
+	}
+}
 
-		token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+// RequireRole allows only callers whose role is at least role (admins pass
+// moderator checks). It must run after JWTAuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !user.HasRole(c.GetString("role"), role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": role + " role required"})
+			return
+		}
+		c.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+// AdminOrSchedulerMiddleware guards operational endpoints such as graph
+// builds. A request passes if it carries the X-Scheduler-Token header
+// matching SCHEDULER_TOKEN, or a valid access token with the admin role.
+// Scheduler requests have no user_id and set "scheduler" in the context
+// instead.
+func AdminOrSchedulerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.GetHeader("X-Scheduler-Token"); token != "" {
+			expected := os.Getenv("SCHEDULER_TOKEN")
+			if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid scheduler token"})
+				return
+			}
+			c.Set("scheduler", true)
+			c.Next()
 			return
 		}
 
-		claims, ok := token.Claims.(*Claims)
+		claims, ok := authenticate(c)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			return
+		}
+		if !user.HasRole(claims.Role, user.RoleAdmin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
 			return
 		}
 
-		// Attach user ID to request context
 		c.Set("user_id", claims.UserID)
-
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
+
+// authenticate validates the bearer token of the request. On failure it
+// aborts with 401 and returns false.
+func authenticate(c *gin.Context) (*Claims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+		return nil, false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+		return nil, false
+	}
+
+	tokenStr := parts[1]
+	secret := os.Getenv("JWT_SECRET")
+
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+
+	if err != nil || !token.Valid {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		return nil, false
+	}
+
+	return claims, true
+}
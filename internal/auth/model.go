@@ -6,10 +6,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// Session is one login of a user, identified by its refresh token. Device
+// details are captured at login and refreshed on every token refresh.
 type Session struct {
-	ID              uuid.UUID `gorm:"type:uuid;primaryKey"`
-	UserID          uuid.UUID `gorm:"type:uuid;not null;index"`
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID           uuid.UUID `gorm:"type:uuid;not null;index"`
 	RefreshTokenHash string    `gorm:"not null"`
-	ExpiresAt       time.Time `gorm:"not null"`
-	CreatedAt       time.Time
+	DeviceLabel      string    `gorm:"default:''"`
+	UserAgent        string    `gorm:"type:text;default:''"`
+	IP               string    `gorm:"column:ip;default:''"`
+	ExpiresAt        time.Time `gorm:"not null;index"`
+	LastUsedAt       time.Time
+	CreatedAt        time.Time
 }
@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"os"
+
+	"github.com/google/uuid"
 )
 
 func GenerateRefreshToken() (string, error) {
@@ -19,3 +23,15 @@ func HashRefreshToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
 }
+
+// deriveRefreshToken returns the token that replaces token in the session
+// sessionID. Successors are derived rather than random so that a retried
+// refresh can be answered with the same successor; without JWT_SECRET they
+// cannot be predicted from the token they replace.
+func deriveRefreshToken(sessionID uuid.UUID, token string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte("refresh-token:"))
+	mac.Write(sessionID[:])
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"devgraph/internal/cache"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `json:"ip"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// ListSessions handles GET /api/sessions.
+// Lists the caller's unexpired sessions, most recently used first; current
+// marks the session of the access token making the request.
+func ListSessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+		current, _ := c.Get("session_id")
+
+		var sessions []Session
+		if err := db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+			Order("last_used_at DESC").
+			Find(&sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load sessions"})
+			return
+		}
+
+		out := make([]SessionResponse, 0, len(sessions))
+		for _, s := range sessions {
+			out = append(out, SessionResponse{
+				ID:          s.ID,
+				DeviceLabel: s.DeviceLabel,
+				UserAgent:   s.UserAgent,
+				IP:          s.IP,
+				CreatedAt:   s.CreatedAt,
+				LastUsedAt:  s.LastUsedAt,
+				ExpiresAt:   s.ExpiresAt,
+				Current:     s.ID == current,
+			})
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// RevokeSession handles DELETE /api/sessions/:id.
+// Access tokens already issued for the session stay valid until they
+// expire; the session can no longer be refreshed.
+func RevokeSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+			return
+		}
+
+		var sessions []Session
+		if err := db.Where("id = ? AND user_id = ?", id, userID).Find(&sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load session"})
+			return
+		}
+		if len(sessions) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+
+		if err := revokeSessions(db, sessions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+	}
+}
+
+// RevokeOtherSessions handles DELETE /api/sessions and revokes every
+// session of the caller except the current one.
+func RevokeOtherSessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+		current, _ := c.Get("session_id")
+		keep, _ := current.(uuid.UUID)
+
+		if err := revokeUserSessions(db, userID, keep); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "sessions revoked"})
+	}
+}
+
+// revokeUserSessions revokes every session of userID except keep, which
+// may be uuid.Nil to revoke all of them.
+func revokeUserSessions(db *gorm.DB, userID, keep uuid.UUID) error {
+	query := db.Where("user_id = ?", userID)
+	if keep != uuid.Nil {
+		query = query.Where("id <> ?", keep)
+	}
+
+	var sessions []Session
+	if err := query.Find(&sessions).Error; err != nil {
+		return err
+	}
+	return revokeSessions(db, sessions)
+}
+
+// revokeSessions deletes the sessions' rows, their rotated tokens and their
+// session: Redis keys. Postgres is authoritative, so a failure to clear
+// Redis is only logged: Refresh requires the row.
+func revokeSessions(db *gorm.DB, sessions []Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(sessions))
+	keys := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+		keys = append(keys, sessionKey(s.RefreshTokenHash))
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id IN ?", ids).Delete(&RotatedRefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&Session{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	redisClient := cache.NewRedisClient()
+	if err := redisClient.Del(cache.Ctx, keys...).Err(); err != nil {
+		log.Println("failed to clear revoked sessions from redis:", err)
+	}
+	return nil
+}
+
+// PurgeExpiredSessions deletes expired sessions, rotated refresh tokens and
+// account tokens, and returns how many sessions were removed. Their Redis
+// keys expire on their own.
+func PurgeExpiredSessions(db *gorm.DB) (int64, error) {
+	now := time.Now()
+	if err := db.Where("expires_at <= ?", now).Delete(&RotatedRefreshToken{}).Error; err != nil {
+		return 0, err
+	}
+	if err := db.Where("expires_at <= ?", now).Delete(&AccountToken{}).Error; err != nil {
+		return 0, err
+	}
+	result := db.Where("expires_at <= ?", now).Delete(&Session{})
+	return result.RowsAffected, result.Error
+}
+
+// StartSessionPurge purges expired sessions every interval.
+func StartSessionPurge(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			n, err := PurgeExpiredSessions(db)
+			if err != nil {
+				log.Println("session purge failed:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Purged %d expired sessions\n", n)
+			}
+		}
+	}()
+}
+
+// deviceLabel derives a short label such as "Firefox on Linux" from a
+// User-Agent header.
+func deviceLabel(userAgent string) string {
+	if strings.TrimSpace(userAgent) == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, o := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			platform = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	// Non-browser clients such as curl/8.4.0: use the product name.
+	return strings.SplitN(strings.Fields(userAgent)[0], "/", 2)[0]
+}
@@ -1,6 +1,10 @@
 package code
 
+import "github.com/google/uuid"
+
 type SubmitCodeRequest struct {
-	Language   string `json:"language" binding:"required"`
-	SourceCode string `json:"source_code" binding:"required"`
+	Language   string     `json:"language" binding:"required"`
+	SourceCode string     `json:"source_code" binding:"required"`
+	ProblemID  *uuid.UUID `json:"problem_id"`
+	Visibility string     `json:"visibility" binding:"omitempty,oneof=public private"`
 }
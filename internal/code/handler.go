@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"devgraph/internal/analysis"
+	"devgraph/internal/problem"
 
 )
 
@@ -27,11 +28,23 @@ func SubmitCode(db *gorm.DB) gin.HandlerFunc {
 
 		userID := userIDRaw.(uuid.UUID)
 
+		if req.ProblemID != nil && !problem.Exists(db, *req.ProblemID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "problem not found"})
+			return
+		}
+
+		visibility := req.Visibility
+		if visibility == "" {
+			visibility = "public"
+		}
+
 		submission := CodeSubmission{
 			ID:         uuid.New(),
 			UserID:     userID,
 			Language:   req.Language,
 			SourceCode: req.SourceCode,
+			ProblemID:  req.ProblemID,
+			Visibility: visibility,
 			CreatedAt:  time.Now(),
 		}
 
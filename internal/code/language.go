@@ -0,0 +1,44 @@
+package code
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// extensionLanguages maps source file extensions to the language names
+// accepted by POST /api/submit.
+var extensionLanguages = map[string]string{
+	".py":   "python",
+	".js":   "javascript",
+	".java": "java",
+	".cpp":  "cpp",
+	".cc":   "cpp",
+	".cxx":  "cpp",
+	".hpp":  "cpp",
+	".h":    "cpp",
+	".go":   "go",
+}
+
+// languageExtensions is the canonical extension for each language.
+var languageExtensions = map[string]string{
+	"python":     ".py",
+	"javascript": ".js",
+	"java":       ".java",
+	"cpp":        ".cpp",
+	"go":         ".go",
+}
+
+// LanguageForPath returns the submission language for a file path, or ""
+// if the extension is not supported.
+func LanguageForPath(path string) string {
+	return extensionLanguages[strings.ToLower(filepath.Ext(path))]
+}
+
+// ExtensionForLanguage returns the canonical file extension for a
+// submission language, falling back to ".txt".
+func ExtensionForLanguage(language string) string {
+	if ext, ok := languageExtensions[strings.ToLower(language)]; ok {
+		return ext
+	}
+	return ".txt"
+}
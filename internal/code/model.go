@@ -7,9 +7,18 @@ import (
 )
 
 type CodeSubmission struct {
-	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
-	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
-	Language   string    `gorm:"not null"`
-	SourceCode string    `gorm:"type:text;not null"`
-	CreatedAt  time.Time
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Language   string     `gorm:"not null"`
+	SourceCode string     `gorm:"type:text;not null"`
+	ProblemID  *uuid.UUID `gorm:"type:uuid;index"`
+	Visibility string     `gorm:"not null;default:'public'"`
+
+	// Set only for submissions imported from a git repository.
+	SourceRepo string `gorm:"default:'';index"`
+	SourcePath string `gorm:"default:''"`
+	CommitSHA  string `gorm:"default:''"`
+	BlobSHA    string `gorm:"default:''"`
+
+	CreatedAt time.Time
 }
@@ -0,0 +1,89 @@
+package connection
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlockUser handles POST /api/blocks/:user_id.
+// Removes every connection, request and follow between the two users.
+func BlockUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		target, ok := targetUser(c, db, userID)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+				userID, target, target, userID).Delete(&ConnectionRequest{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+				userID, target, target, userID).Delete(&Follow{}).Error; err != nil {
+				return err
+			}
+			block := Block{BlockerID: userID, BlockedID: target, CreatedAt: time.Now()}
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to block user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "user blocked", "user_id": target})
+	}
+}
+
+// UnblockUser handles DELETE /api/blocks/:user_id.
+// Removed connections and follows are not restored.
+func UnblockUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		target, err := uuid.Parse(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+
+		result := db.Where("blocker_id = ? AND blocked_id = ?", userID, target).Delete(&Block{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unblock user"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user is not blocked"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "user unblocked", "user_id": target})
+	}
+}
+
+// ListBlocks handles GET /api/blocks and lists the users the caller blocked.
+func ListBlocks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		users := []ConnectionUser{}
+		if err := db.Table("blocks b").
+			Select("u.id, u.username, u.avatar_url, b.created_at AS since").
+			Joins("JOIN users u ON u.id = b.blocked_id").
+			Where("b.blocker_id = ?", userID).
+			Order("b.created_at DESC").
+			Scan(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load blocks"})
+			return
+		}
+
+		c.JSON(http.StatusOK, users)
+	}
+}
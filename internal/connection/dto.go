@@ -0,0 +1,27 @@
+package connection
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ConnectRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+// ConnectionUser describes the other developer of a connection, request,
+// follow or block.
+type ConnectionUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatar_url"`
+	Since     time.Time `json:"since"`
+}
+
+type RequestResponse struct {
+	ID        uuid.UUID      `json:"id"`
+	User      ConnectionUser `json:"user"`
+	Status    string         `json:"status"`
+	Direction string         `json:"direction"`
+}
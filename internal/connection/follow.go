@@ -0,0 +1,95 @@
+package connection
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FollowUser handles POST /api/follows/:user_id.
+// Following is one-way and needs no approval; following twice is a no-op.
+func FollowUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		target, ok := targetUser(c, db, userID)
+		if !ok || !reachable(c, db, userID, target) {
+			return
+		}
+
+		follow := Follow{FollowerID: userID, FolloweeID: target, CreatedAt: time.Now()}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to follow user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "following", "user_id": target})
+	}
+}
+
+// UnfollowUser handles DELETE /api/follows/:user_id.
+func UnfollowUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		target, err := uuid.Parse(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+
+		result := db.Where("follower_id = ? AND followee_id = ?", userID, target).Delete(&Follow{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unfollow user"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not following this user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "unfollowed", "user_id": target})
+	}
+}
+
+// ListFollowers handles GET /api/users/:id/followers.
+func ListFollowers(db *gorm.DB) gin.HandlerFunc {
+	return listFollows(db, "f.followee_id", "f.follower_id")
+}
+
+// ListFollowing handles GET /api/users/:id/following.
+func ListFollowing(db *gorm.DB) gin.HandlerFunc {
+	return listFollows(db, "f.follower_id", "f.followee_id")
+}
+
+// listFollows lists the users on the other column of the :id user's
+// follows, most recent first.
+func listFollows(db *gorm.DB, self, other string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		if !userExists(c, db, id) {
+			return
+		}
+
+		users := []ConnectionUser{}
+		if err := db.Table("follows f").
+			Select("u.id, u.username, u.avatar_url, f.created_at AS since").
+			Joins("JOIN users u ON u.id = "+other).
+			Where(self+" = ?", id).
+			Order("f.created_at DESC").
+			Scan(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load follows"})
+			return
+		}
+
+		c.JSON(http.StatusOK, users)
+	}
+}
@@ -0,0 +1,272 @@
+package connection
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SendRequest handles POST /api/connections/requests.
+// If the other developer already asked to connect, their request is
+// accepted instead of sending a new one. Declined requests between the two
+// are replaced.
+func SendRequest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var req ConnectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		target := req.UserID
+		if target == userID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot connect with yourself"})
+			return
+		}
+		if !userExists(c, db, target) || !reachable(c, db, userID, target) {
+			return
+		}
+
+		var existing []ConnectionRequest
+		if err := db.Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+			userID, target, target, userID).Find(&existing).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load connection requests"})
+			return
+		}
+
+		for _, r := range existing {
+			switch {
+			case r.Status == StatusAccepted:
+				c.JSON(http.StatusConflict, gin.H{"error": "already connected"})
+				return
+			case r.Status == StatusPending && r.RequesterID == userID:
+				c.JSON(http.StatusConflict, gin.H{"error": "request already sent", "request_id": r.ID})
+				return
+			case r.Status == StatusPending:
+				if err := respond(db, &r, StatusAccepted); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept request"})
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"request_id": r.ID, "status": r.Status})
+				return
+			}
+		}
+
+		request := ConnectionRequest{
+			ID:          uuid.New(),
+			RequesterID: userID,
+			AddresseeID: target,
+			Status:      StatusPending,
+			CreatedAt:   time.Now(),
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if len(existing) > 0 {
+				if err := tx.Delete(&existing).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Create(&request).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send request"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"request_id": request.ID, "status": request.Status})
+	}
+}
+
+// ListRequests handles GET /api/connections/requests.
+// Query parameter direction selects incoming (default) or outgoing pending
+// requests.
+func ListRequests(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		direction := c.DefaultQuery("direction", "incoming")
+		self, other := "cr.addressee_id", "cr.requester_id"
+		switch direction {
+		case "incoming":
+		case "outgoing":
+			self, other = other, self
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be incoming or outgoing"})
+			return
+		}
+
+		var rows []struct {
+			ID        uuid.UUID
+			UserID    uuid.UUID
+			Username  string
+			AvatarURL string
+			CreatedAt time.Time
+		}
+		if err := db.Table("connection_requests cr").
+			Select("cr.id, u.id AS user_id, u.username, u.avatar_url, cr.created_at").
+			Joins("JOIN users u ON u.id = "+other).
+			Where(self+" = ? AND cr.status = ?", userID, StatusPending).
+			Order("cr.created_at DESC").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load requests"})
+			return
+		}
+
+		requests := make([]RequestResponse, 0, len(rows))
+		for _, r := range rows {
+			requests = append(requests, RequestResponse{
+				ID: r.ID,
+				User: ConnectionUser{
+					ID:        r.UserID,
+					Username:  r.Username,
+					AvatarURL: r.AvatarURL,
+					Since:     r.CreatedAt,
+				},
+				Status:    StatusPending,
+				Direction: direction,
+			})
+		}
+		c.JSON(http.StatusOK, requests)
+	}
+}
+
+// AcceptRequest handles POST /api/connections/requests/:id/accept.
+func AcceptRequest(db *gorm.DB) gin.HandlerFunc {
+	return answerRequest(db, StatusAccepted)
+}
+
+// DeclineRequest handles POST /api/connections/requests/:id/decline.
+func DeclineRequest(db *gorm.DB) gin.HandlerFunc {
+	return answerRequest(db, StatusDeclined)
+}
+
+// answerRequest lets the addressee of a pending request accept or decline
+// it.
+func answerRequest(db *gorm.DB, status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		request, ok := loadRequest(c, db, "addressee_id = ?", userID)
+		if !ok {
+			return
+		}
+		if request.Status != StatusPending {
+			c.JSON(http.StatusConflict, gin.H{"error": "request already answered", "status": request.Status})
+			return
+		}
+
+		if err := respond(db, request, status); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"request_id": request.ID, "status": request.Status})
+	}
+}
+
+// CancelRequest handles DELETE /api/connections/requests/:id.
+// The requester may withdraw a request that is still pending.
+func CancelRequest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		request, ok := loadRequest(c, db, "requester_id = ?", userID)
+		if !ok {
+			return
+		}
+		if request.Status != StatusPending {
+			c.JSON(http.StatusConflict, gin.H{"error": "request already answered", "status": request.Status})
+			return
+		}
+
+		if err := db.Delete(request).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel request"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "request cancelled"})
+	}
+}
+
+// ListConnections handles GET /api/connections.
+func ListConnections(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		connections := []ConnectionUser{}
+		if err := db.Raw(`
+			SELECT u.id, u.username, u.avatar_url, cr.responded_at AS since
+			FROM connection_requests cr
+			JOIN users u ON u.id = CASE WHEN cr.requester_id = ? THEN cr.addressee_id ELSE cr.requester_id END
+			WHERE (cr.requester_id = ? OR cr.addressee_id = ?) AND cr.status = ?
+			ORDER BY u.username
+		`, userID, userID, userID, StatusAccepted).Scan(&connections).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load connections"})
+			return
+		}
+
+		c.JSON(http.StatusOK, connections)
+	}
+}
+
+// RemoveConnection handles DELETE /api/connections/:user_id.
+func RemoveConnection(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		target, err := uuid.Parse(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+
+		result := db.Where("((requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)) AND status = ?",
+			userID, target, target, userID, StatusAccepted).Delete(&ConnectionRequest{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove connection"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "connection not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "connection removed"})
+	}
+}
+
+// loadRequest loads the :id request, which must match the given ownership
+// condition; other requests are reported as missing.
+func loadRequest(c *gin.Context, db *gorm.DB, owner string, userID uuid.UUID) (*ConnectionRequest, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request id"})
+		return nil, false
+	}
+
+	var request ConnectionRequest
+	err = db.Where("id = ?", id).Where(owner, userID).Take(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load request"})
+		return nil, false
+	}
+	return &request, true
+}
+
+func respond(db *gorm.DB, request *ConnectionRequest, status string) error {
+	now := time.Now()
+	request.Status = status
+	request.RespondedAt = &now
+	return db.Model(request).Updates(map[string]interface{}{
+		"status":       status,
+		"responded_at": now,
+	}).Error
+}
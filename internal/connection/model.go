@@ -0,0 +1,41 @@
+package connection
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusDeclined = "declined"
+)
+
+// ConnectionRequest is a request from one developer to connect with
+// another. An accepted request is a mutual connection; at most one request
+// exists per ordered pair, and a declined one may be sent again.
+type ConnectionRequest struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RequesterID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_connection_pair"`
+	AddresseeID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_connection_pair;index"`
+	Status      string    `gorm:"not null;index"`
+	CreatedAt   time.Time
+	RespondedAt *time.Time
+}
+
+// Follow is a one-way subscription to another developer's activity.
+type Follow struct {
+	FollowerID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FolloweeID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	CreatedAt  time.Time
+}
+
+// Block hides two developers from each other. Blocking removes any
+// connection, pending request and follow between them and prevents new ones
+// in either direction.
+type Block struct {
+	BlockerID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BlockedID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time
+}
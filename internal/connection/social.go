@@ -0,0 +1,79 @@
+package connection
+
+import (
+	"net/http"
+
+	"devgraph/internal/user"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExcludedUsers returns the developers that should not be recommended to
+// userID: existing connections, pending requests in either direction and
+// blocks in either direction.
+func ExcludedUsers(db *gorm.DB, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Raw(`
+		SELECT CASE WHEN requester_id = @user THEN addressee_id ELSE requester_id END
+		FROM connection_requests
+		WHERE (requester_id = @user OR addressee_id = @user) AND status IN @statuses
+		UNION
+		SELECT CASE WHEN blocker_id = @user THEN blocked_id ELSE blocker_id END
+		FROM blocks
+		WHERE blocker_id = @user OR blocked_id = @user
+	`, map[string]interface{}{
+		"user":     userID,
+		"statuses": []string{StatusPending, StatusAccepted},
+	}).Scan(&ids).Error
+	return ids, err
+}
+
+// blocked reports whether either user has blocked the other.
+func blocked(db *gorm.DB, a, b uuid.UUID) (bool, error) {
+	var n int64
+	err := db.Model(&Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// targetUser parses the :user_id parameter and writes an error unless it
+// names another existing developer.
+func targetUser(c *gin.Context, db *gorm.DB, userID uuid.UUID) (uuid.UUID, bool) {
+	target, err := uuid.Parse(c.Param("user_id"))
+	if err != nil || target == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return target, userExists(c, db, target)
+}
+
+func userExists(c *gin.Context, db *gorm.DB, id uuid.UUID) bool {
+	var n int64
+	if err := db.Model(&user.User{}).Where("id = ?", id).Count(&n).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user"})
+		return false
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return false
+	}
+	return true
+}
+
+// reachable writes a 404 when a block separates the two users, so a
+// blocked user cannot tell they were blocked.
+func reachable(c *gin.Context, db *gorm.DB, userID, target uuid.UUID) bool {
+	isBlocked, err := blocked(db, userID, target)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check blocks"})
+		return false
+	}
+	if isBlocked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return false
+	}
+	return true
+}
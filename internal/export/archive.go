@@ -0,0 +1,230 @@
+package export
+
+// archive.go — personal data archive layout
+//
+// Every archive is a zip with the following entries:
+//
+//	manifest.json                  format name, ArchiveVersion, counts
+//	profile.json                   the user.User row (no password hash)
+//	submissions/<id><ext>          raw source of each CodeSubmission
+//	submissions/<id>.json          submission metadata
+//	analyses/<submission_id>.json  CodeAnalysis rows plus detected patterns
+//	similarity_edges.json          UserSimilarityEdge rows touching the user
+//
+// ArchiveVersion must be bumped whenever an entry is added, removed or
+// changes shape so that an importer can tell which layout it is reading.
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"devgraph/internal/analysis"
+	"devgraph/internal/code"
+	"devgraph/internal/graph"
+	"devgraph/internal/user"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ArchiveFormat  = "devgraph-export"
+	ArchiveVersion = 1
+)
+
+type Manifest struct {
+	Format          string    `json:"format"`
+	Version         int       `json:"version"`
+	UserID          uuid.UUID `json:"user_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	Submissions     int       `json:"submissions"`
+	Analyses        int       `json:"analyses"`
+	SimilarityEdges int       `json:"similarity_edges"`
+}
+
+type profileEntry struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	AvatarURL string    `json:"avatar_url"`
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type submissionEntry struct {
+	ID         uuid.UUID  `json:"id"`
+	Language   string     `json:"language"`
+	File       string     `json:"file"`
+	ProblemID  *uuid.UUID `json:"problem_id"`
+	Visibility string     `json:"visibility"`
+	SourceRepo string     `json:"source_repo,omitempty"`
+	SourcePath string     `json:"source_path,omitempty"`
+	CommitSHA  string     `json:"commit_sha,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type analysisEntry struct {
+	ID              uuid.UUID `json:"id"`
+	SubmissionID    uuid.UUID `json:"submission_id"`
+	TimeComplexity  string    `json:"time_complexity"`
+	SpaceComplexity string    `json:"space_complexity"`
+	Issues          string    `json:"issues"`
+	Patterns        []string  `json:"patterns"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type edgeEntry struct {
+	UserA      uuid.UUID `json:"user_a"`
+	UserB      uuid.UUID `json:"user_b"`
+	Similarity float64   `json:"similarity"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WriteArchive streams the complete export for userID into w as a zip.
+func WriteArchive(db *gorm.DB, userID uuid.UUID, w io.Writer) error {
+	var u user.User
+	if err := db.Where("id = ?", userID).First(&u).Error; err != nil {
+		return err
+	}
+
+	var submissions []code.CodeSubmission
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&submissions).Error; err != nil {
+		return err
+	}
+
+	version, err := graph.ActiveVersion(db)
+	if err != nil {
+		return err
+	}
+
+	var edges []graph.UserSimilarityEdge
+	if err := db.Where("version = ? AND (user_a = ? OR user_b = ?)", version, userID, userID).
+		Find(&edges).Error; err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	if err := writeJSON(zw, "profile.json", profileEntry{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		AvatarURL: u.AvatarURL,
+		Bio:       u.Bio,
+		CreatedAt: u.CreatedAt,
+	}); err != nil {
+		return err
+	}
+
+	analysisCount := 0
+	for _, s := range submissions {
+		file := "submissions/" + s.ID.String() + code.ExtensionForLanguage(s.Language)
+
+		f, err := zw.Create(file)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, s.SourceCode); err != nil {
+			return err
+		}
+
+		if err := writeJSON(zw, "submissions/"+s.ID.String()+".json", submissionEntry{
+			ID:         s.ID,
+			Language:   s.Language,
+			File:       file,
+			ProblemID:  s.ProblemID,
+			Visibility: s.Visibility,
+			SourceRepo: s.SourceRepo,
+			SourcePath: s.SourcePath,
+			CommitSHA:  s.CommitSHA,
+			CreatedAt:  s.CreatedAt,
+		}); err != nil {
+			return err
+		}
+
+		entries, err := loadAnalyses(db, s.ID)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		analysisCount += len(entries)
+		if err := writeJSON(zw, "analyses/"+s.ID.String()+".json", entries); err != nil {
+			return err
+		}
+	}
+
+	edgeEntries := make([]edgeEntry, 0, len(edges))
+	for _, e := range edges {
+		edgeEntries = append(edgeEntries, edgeEntry{
+			UserA:      e.UserA,
+			UserB:      e.UserB,
+			Similarity: e.Similarity,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, "similarity_edges.json", edgeEntries); err != nil {
+		return err
+	}
+
+	if err := writeJSON(zw, "manifest.json", Manifest{
+		Format:          ArchiveFormat,
+		Version:         ArchiveVersion,
+		UserID:          userID,
+		CreatedAt:       time.Now(),
+		Submissions:     len(submissions),
+		Analyses:        analysisCount,
+		SimilarityEdges: len(edgeEntries),
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func loadAnalyses(db *gorm.DB, submissionID uuid.UUID) ([]analysisEntry, error) {
+	var rows []analysis.CodeAnalysis
+	if err := db.Where("submission_id = ?", submissionID).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	patterns := []string{}
+	if err := db.Raw(`
+		SELECT ap.name FROM algorithm_patterns ap
+		JOIN submission_patterns sp ON sp.pattern_id = ap.id
+		WHERE sp.submission_id = ?
+		ORDER BY ap.name
+	`, submissionID).Scan(&patterns).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]analysisEntry, len(rows))
+	for i, a := range rows {
+		entries[i] = analysisEntry{
+			ID:              a.ID,
+			SubmissionID:    a.SubmissionID,
+			TimeComplexity:  a.TimeComplexity,
+			SpaceComplexity: a.SpaceComplexity,
+			Issues:          a.Issues,
+			Patterns:        patterns,
+			CreatedAt:       a.CreatedAt,
+		}
+	}
+	return entries, nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
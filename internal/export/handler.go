@@ -0,0 +1,147 @@
+package export
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"devgraph/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	ExpiresAt   string    `json:"expires_at"`
+	CreatedAt   string    `json:"created_at"`
+	CompletedAt string    `json:"completed_at,omitempty"`
+}
+
+// errExportInProgress is returned when the caller already has an unfinished
+// export.
+var errExportInProgress = errors.New("an export is already in progress")
+
+// StartExport handles POST /api/export.
+// Queues an archive build and returns the job ID together with the
+// download token; the token is shown only once. A user can have one
+// pending or running export at a time.
+func StartExport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		token, err := auth.GenerateRefreshToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate download token"})
+			return
+		}
+
+		job := ExportJob{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Status:    StatusPending,
+			TokenHash: auth.HashRefreshToken(token),
+			ExpiresAt: time.Now().Add(downloadTTL),
+			CreatedAt: time.Now(),
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Locking the user row serialises concurrent requests by the
+			// same user between the check and the insert.
+			var owner struct{ ID uuid.UUID }
+			if err := tx.Table("users").
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id").
+				Where("id = ?", userID).
+				Take(&owner).Error; err != nil {
+				return err
+			}
+
+			var active int64
+			if err := tx.Model(&ExportJob{}).
+				Where("user_id = ? AND status IN ? AND created_at > ?",
+					userID, []string{StatusPending, StatusRunning}, time.Now().Add(-maxJobRuntime)).
+				Count(&active).Error; err != nil {
+				return err
+			}
+			if active > 0 {
+				return errExportInProgress
+			}
+			return tx.Create(&job).Error
+		})
+		if errors.Is(err, errExportInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create export job"})
+			return
+		}
+
+		go runJob(db, job)
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"job_id":       job.ID,
+			"status":       job.Status,
+			"download_url": "/export/download/" + token,
+			"expires_at":   job.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// GetExport handles GET /api/export/:id.
+func GetExport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var job ExportJob
+		if err := db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&job).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+			return
+		}
+
+		resp := JobResponse{
+			ID:        job.ID,
+			Status:    job.Status,
+			Error:     job.Error,
+			ExpiresAt: job.ExpiresAt.Format(time.RFC3339),
+			CreatedAt: job.CreatedAt.Format(time.RFC3339),
+		}
+		if job.CompletedAt != nil {
+			resp.CompletedAt = job.CompletedAt.Format(time.RFC3339)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Download handles GET /export/download/:token.
+// The token itself authorizes the download so the link works from a
+// browser without an Authorization header.
+func Download(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hashed := auth.HashRefreshToken(c.Param("token"))
+
+		var job ExportJob
+		if err := db.Where("token_hash = ?", hashed).First(&job).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+			return
+		}
+
+		if time.Now().After(job.ExpiresAt) {
+			c.JSON(http.StatusGone, gin.H{"error": "download link expired"})
+			return
+		}
+
+		if job.Status != StatusDone {
+			c.JSON(http.StatusConflict, gin.H{"error": "export is not ready", "status": job.Status})
+			return
+		}
+
+		filename := "devgraph-export-" + job.CreatedAt.Format("20060102") + ".zip"
+		c.FileAttachment(job.FilePath, filename)
+	}
+}
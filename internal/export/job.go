@@ -0,0 +1,113 @@
+package export
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// downloadTTL is how long a finished archive can be downloaded.
+	downloadTTL = 24 * time.Hour
+
+	// maxJobRuntime is how long a pending or running job blocks new
+	// exports by the same user; older ones are assumed lost in a restart.
+	maxJobRuntime = 30 * time.Minute
+)
+
+// exportDir is where archives are written. EXPORT_DIR overrides the
+// default temp directory.
+func exportDir() string {
+	if dir := os.Getenv("EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "devgraph-exports")
+}
+
+// runJob builds the archive for a job and records the outcome. It is run
+// in its own goroutine by StartExport.
+func runJob(db *gorm.DB, job ExportJob) {
+	db.Model(&ExportJob{}).Where("id = ?", job.ID).Update("status", StatusRunning)
+
+	path, err := writeArchiveFile(db, job)
+	now := time.Now()
+
+	if err != nil {
+		log.Printf("export %s failed: %v\n", job.ID, err)
+		db.Model(&ExportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":       StatusFailed,
+			"error":        "failed to build archive",
+			"completed_at": now,
+		})
+		return
+	}
+
+	db.Model(&ExportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":       StatusDone,
+		"file_path":    path,
+		"completed_at": now,
+	})
+	log.Printf("export %s ready\n", job.ID)
+}
+
+func writeArchiveFile(db *gorm.DB, job ExportJob) (string, error) {
+	dir := exportDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, job.ID.String()+".zip")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	if err := WriteArchive(db, job.UserID, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// PurgeExpired deletes archives whose download window has passed along
+// with their job rows.
+func PurgeExpired(db *gorm.DB) {
+	var expired []ExportJob
+	if err := db.Where("expires_at < ?", time.Now()).Find(&expired).Error; err != nil {
+		log.Println("failed to load expired exports:", err)
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(expired))
+	for _, job := range expired {
+		if job.FilePath != "" {
+			os.Remove(job.FilePath)
+		}
+		ids = append(ids, job.ID)
+	}
+	if len(ids) > 0 {
+		db.Where("id IN ?", ids).Delete(&ExportJob{})
+	}
+}
+
+// StartExportPurge deletes expired archives every interval, so they do not
+// outlive their download window on instances where nobody starts an export.
+func StartExportPurge(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			PurgeExpired(db)
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package export
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// ExportJob tracks one asynchronous data-export archive. Only the SHA-256
+// of the download token is stored.
+type ExportJob struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	Status      string    `gorm:"not null"`
+	FilePath    string    `gorm:"default:''"`
+	TokenHash   string    `gorm:"not null;uniqueIndex"`
+	Error       string    `gorm:"default:''"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
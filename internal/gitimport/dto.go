@@ -0,0 +1,11 @@
+package gitimport
+
+// ImportRequest is bound from JSON or multipart form data. Exactly one of
+// Path (a repository under GIT_IMPORT_ROOT) or an uploaded "bundle" file
+// must be supplied. Repo names the repository for re-imports and defaults
+// to Path.
+type ImportRequest struct {
+	Repo string `json:"repo" form:"repo"`
+	Path string `json:"path" form:"path"`
+	Ref  string `json:"ref" form:"ref"`
+}
@@ -0,0 +1,155 @@
+package gitimport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cloneSizeInterval is how often a running bundle clone is measured.
+const cloneSizeInterval = 100 * time.Millisecond
+
+// treeEntry is one blob listed by `git ls-tree -r -l`.
+type treeEntry struct {
+	Blob string
+	Size int64
+	Path string
+}
+
+// runGit executes git against a local repository. Network transports are
+// disabled so an import can never reach outside the machine.
+func runGit(dir string, args ...string) ([]byte, error) {
+	full := append([]string{"-c", "safe.directory=*", "-C", dir}, args...)
+	cmd := exec.Command("git", full...)
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_ALLOW_PROTOCOL=file",
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// resolveCommit turns a ref (branch, tag, SHA, HEAD~2 …) into a full SHA.
+func resolveCommit(dir, ref string) (string, error) {
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q", ref)
+	}
+	out, err := runGit(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown ref %q", ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// listTree returns every blob reachable from the commit's tree.
+func listTree(dir, commit string) ([]treeEntry, error) {
+	out, err := runGit(dir, "ls-tree", "-r", "-l", "-z", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []treeEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Split(splitNUL)
+	for scanner.Scan() {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		line := scanner.Text()
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		entries = append(entries, treeEntry{Blob: fields[2], Size: size, Path: path})
+	}
+	return entries, scanner.Err()
+}
+
+func readBlob(dir, blob string) ([]byte, error) {
+	return runGit(dir, "cat-file", "blob", blob)
+}
+
+// errCloneTooLarge is returned when an unpacked bundle exceeds its limit.
+var errCloneTooLarge = errors.New("repository is too large to import")
+
+// cloneBundle unpacks a git bundle into a bare repository at dest. The size
+// of dest is checked while git runs and the clone is killed once it grows
+// past limit bytes.
+func cloneBundle(bundlePath, dest string, limit int64) error {
+	cmd := exec.Command("git", "clone", "--bare", "--quiet", bundlePath, dest)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL=file")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("git clone bundle: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	ticker := time.NewTicker(cloneSizeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("git clone bundle: %v: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			if dirSize(dest) > limit {
+				return errCloneTooLarge
+			}
+			return nil
+		case <-ticker.C:
+			if dirSize(dest) > limit {
+				cmd.Process.Kill()
+				<-done
+				return errCloneTooLarge
+			}
+		}
+	}
+}
+
+// dirSize returns the total size of the regular files under dir. Files
+// that disappear while it walks are ignored.
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+func splitNUL(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,176 @@
+package gitimport
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"devgraph/internal/analysis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxBundleSize bounds an uploaded git bundle.
+	maxBundleSize = 50 << 20
+
+	// maxCloneSize bounds the bare repository a bundle is unpacked into.
+	maxCloneSize = 2 * maxBundleSize
+
+	// maxFormOverhead allows for the other form fields and multipart
+	// framing on top of the bundle itself.
+	maxFormOverhead = 1 << 20
+)
+
+// ImportGit handles POST /api/import/git.
+//
+// A repository is read either from an uploaded git bundle (multipart field
+// "bundle", at most maxBundleSize bytes) or from a local path, which must
+// resolve inside the directory named by GIT_IMPORT_ROOT; path imports are
+// disabled when it is unset.
+func ImportGit(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBundleSize+maxFormOverhead)
+
+		var req ImportRequest
+		if err := c.ShouldBind(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "bundle is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Ref == "" {
+			req.Ref = "HEAD"
+		}
+
+		var dir, repo string
+
+		if file, err := c.FormFile("bundle"); err == nil {
+			if req.Repo == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "repo is required for bundle imports"})
+				return
+			}
+			if file.Size > maxBundleSize {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "bundle is too large"})
+				return
+			}
+
+			tmp, err := os.MkdirTemp("", "devgraph-import-")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to prepare import"})
+				return
+			}
+			defer os.RemoveAll(tmp)
+
+			bundlePath := filepath.Join(tmp, "repo.bundle")
+			if err := c.SaveUploadedFile(file, bundlePath); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store bundle"})
+				return
+			}
+
+			dir = filepath.Join(tmp, "repo.git")
+			if err := cloneBundle(bundlePath, dir, maxCloneSize); err != nil {
+				if errors.Is(err, errCloneTooLarge) {
+					c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid git bundle"})
+				return
+			}
+			repo = req.Repo
+		} else {
+			resolved, err := resolveRepoPath(req.Path)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			dir = resolved
+			repo = req.Repo
+			if repo == "" {
+				repo = req.Path
+			}
+		}
+
+		record, ids, err := ImportRepository(db, userID, repo, dir, req.Ref)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// The queue is bounded; feed it from the background so a large
+		// import does not hold the request open.
+		go func() {
+			for _, id := range ids {
+				analysis.JobQueue <- id
+			}
+		}()
+
+		c.JSON(http.StatusCreated, gin.H{
+			"import_id":      record.ID,
+			"repo":           record.Repo,
+			"commit_sha":     record.CommitSHA,
+			"imported":       record.Imported,
+			"unchanged":      record.Unchanged,
+			"submission_ids": ids,
+		})
+	}
+}
+
+// GetImports handles GET /api/import/git.
+// Lists the caller's previous imports, newest first.
+func GetImports(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var imports []RepoImport
+		if err := db.Where("user_id = ?", userID).
+			Order("created_at DESC").
+			Find(&imports).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load imports"})
+			return
+		}
+
+		c.JSON(http.StatusOK, imports)
+	}
+}
+
+// resolveRepoPath confines path imports to GIT_IMPORT_ROOT so that users
+// cannot read arbitrary repositories on the server.
+func resolveRepoPath(path string) (string, error) {
+	root := os.Getenv("GIT_IMPORT_ROOT")
+	if root == "" {
+		return "", errors.New("path imports are disabled; upload a bundle instead")
+	}
+	if path == "" {
+		return "", errors.New("path or bundle is required")
+	}
+
+	rootAbs, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", errors.New("import root is not available")
+	}
+
+	candidate := path
+	if !filepath.IsAbs(candidate) {
+		candidate = filepath.Join(rootAbs, candidate)
+	}
+	resolved, err := filepath.EvalSymlinks(candidate)
+	if err != nil {
+		return "", errors.New("repository not found")
+	}
+
+	rel, err := filepath.Rel(rootAbs, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("repository must be inside the import root")
+	}
+	return resolved, nil
+}
@@ -0,0 +1,139 @@
+package gitimport
+
+import (
+	"bytes"
+	"time"
+	"unicode/utf8"
+
+	"devgraph/internal/code"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxFileSize skips generated or vendored blobs that are too large to
+	// be meaningful analysis input.
+	maxFileSize = 256 * 1024
+
+	// maxFilesPerImport bounds how many submissions a single import creates.
+	maxFilesPerImport = 500
+)
+
+// ImportRepository creates one CodeSubmission per supported source file in
+// the repository at ref. Files whose blob is identical to the latest
+// submission already imported for the same (user, repo, path) are skipped,
+// so re-importing a newer ref only analyses what changed.
+//
+// It returns the recorded import and the IDs of the new submissions; the
+// caller is responsible for queueing those for analysis.
+func ImportRepository(db *gorm.DB, userID uuid.UUID, repo, dir, ref string) (*RepoImport, []uuid.UUID, error) {
+	commit, err := resolveCommit(dir, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := listTree(dir, commit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	known, err := latestBlobs(db, userID, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	submissions := []code.CodeSubmission{}
+	unchanged := 0
+
+	for _, e := range entries {
+		if len(submissions) >= maxFilesPerImport {
+			break
+		}
+
+		language := code.LanguageForPath(e.Path)
+		if language == "" || e.Size == 0 || e.Size > maxFileSize {
+			continue
+		}
+		if known[e.Path] == e.Blob {
+			unchanged++
+			continue
+		}
+
+		content, err := readBlob(dir, e.Blob)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+			continue // binary file with a source extension
+		}
+
+		submissions = append(submissions, code.CodeSubmission{
+			ID:         uuid.New(),
+			UserID:     userID,
+			Language:   language,
+			SourceCode: string(content),
+			Visibility: "private",
+			SourceRepo: repo,
+			SourcePath: e.Path,
+			CommitSHA:  commit,
+			BlobSHA:    e.Blob,
+			CreatedAt:  now,
+		})
+	}
+
+	record := RepoImport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Repo:      repo,
+		Ref:       ref,
+		CommitSHA: commit,
+		Imported:  len(submissions),
+		Unchanged: unchanged,
+		CreatedAt: now,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(submissions) > 0 {
+			if err := tx.CreateInBatches(submissions, 100).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]uuid.UUID, len(submissions))
+	for i, s := range submissions {
+		ids[i] = s.ID
+	}
+	return &record, ids, nil
+}
+
+// latestBlobs maps each previously imported path of a repository to the
+// blob SHA of its most recent submission.
+func latestBlobs(db *gorm.DB, userID uuid.UUID, repo string) (map[string]string, error) {
+	var rows []struct {
+		SourcePath string
+		BlobSHA    string
+	}
+
+	err := db.Raw(`
+		SELECT DISTINCT ON (source_path) source_path, blob_sha
+		FROM code_submissions
+		WHERE user_id = ? AND source_repo = ?
+		ORDER BY source_path, created_at DESC
+	`, userID, repo).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]string, len(rows))
+	for _, r := range rows {
+		known[r.SourcePath] = r.BlobSHA
+	}
+	return known, nil
+}
@@ -0,0 +1,19 @@
+package gitimport
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepoImport records one import run of a repository at a resolved commit.
+type RepoImport struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Repo      string    `gorm:"not null;index" json:"repo"`
+	Ref       string    `gorm:"not null" json:"ref"`
+	CommitSHA string    `gorm:"not null" json:"commit_sha"`
+	Imported  int       `gorm:"not null" json:"imported"`
+	Unchanged int       `gorm:"not null" json:"unchanged"`
+	CreatedAt time.Time `json:"created_at"`
+}
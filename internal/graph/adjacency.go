@@ -0,0 +1,251 @@
+package graph
+
+// adjacency.go — in-memory adjacency cache of the active graph version
+//
+// Graph queries walk many hops, which would cost one query per hop against
+// user_similarity_edges. Instead the active version is loaded once into an
+// adjacency list and shared by all queries. The cache is rebuilt after each
+// full build and reloaded lazily whenever the active version changes (e.g.
+// a rollback).
+//
+// Incremental edge updates bump the version's Generation and record it on
+// the user's GraphUserUpdate row. Every process compares its snapshot's
+// generation with the database on each load and, when it lags behind,
+// reloads only the edges of the users updated since and applies them to a
+// copy of the snapshot. Snapshots handed out earlier are never modified.
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxAdjacencyCatchUp is the number of updated users above which a lagging
+// snapshot is reloaded in full rather than patched.
+const maxAdjacencyCatchUp = 1000
+
+type Neighbour struct {
+	UserID     uuid.UUID
+	Similarity float64
+}
+
+// Adjacency is an immutable snapshot of one graph version at one
+// generation. Neighbour lists are sorted by similarity, strongest first.
+type Adjacency struct {
+	Version    uint
+	Generation int64
+	neighbours map[uuid.UUID][]Neighbour
+}
+
+// Neighbours returns the neighbours of userID. The slice must not be
+// modified.
+func (a *Adjacency) Neighbours(userID uuid.UUID) []Neighbour {
+	return a.neighbours[userID]
+}
+
+// Has reports whether userID has at least one edge.
+func (a *Adjacency) Has(userID uuid.UUID) bool {
+	return len(a.neighbours[userID]) > 0
+}
+
+var adjacencyCache struct {
+	sync.Mutex
+	adj   *Adjacency
+	stale bool
+}
+
+// LoadAdjacency returns the adjacency of the active graph version. The
+// cache is loaded in full when it is empty, stale or holds another version,
+// and patched with the users updated since when only its generation lags.
+func LoadAdjacency(db *gorm.DB) (*Adjacency, error) {
+	var active GraphVersion
+	err := db.Select("id, generation").
+		Where("status = ?", VersionActive).
+		Order("id DESC").
+		Take(&active).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	adjacencyCache.Lock()
+	defer adjacencyCache.Unlock()
+
+	if adj := adjacencyCache.adj; adj != nil && adj.Version == active.ID && !adjacencyCache.stale {
+		if adj.Generation >= active.Generation {
+			return adj, nil
+		}
+		next, err := catchUpAdjacency(db, adj, active.Generation)
+		if err != nil {
+			return nil, err
+		}
+		if next != nil {
+			adjacencyCache.adj = next
+			return next, nil
+		}
+	}
+
+	adj, err := loadAdjacency(db, active.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Edges committed after the generation was read may already be in adj;
+	// replaying them on the next catch-up is harmless.
+	adj.Generation = active.Generation
+	adjacencyCache.adj = adj
+	adjacencyCache.stale = false
+	return adj, nil
+}
+
+// RefreshAdjacency reloads the cache from the active version.
+func RefreshAdjacency(db *gorm.DB) error {
+	InvalidateAdjacency()
+	_, err := LoadAdjacency(db)
+	return err
+}
+
+// InvalidateAdjacency marks the cache stale so the next query reloads it.
+func InvalidateAdjacency() {
+	adjacencyCache.Lock()
+	adjacencyCache.stale = true
+	adjacencyCache.Unlock()
+}
+
+// catchUpAdjacency returns a copy of adj at generation with the edges of
+// every user updated since reloaded, or nil when so many users changed that
+// a full reload is cheaper.
+func catchUpAdjacency(db *gorm.DB, adj *Adjacency, generation int64) (*Adjacency, error) {
+	var users []uuid.UUID
+	if err := db.Model(&GraphUserUpdate{}).
+		Where("version = ? AND generation > ?", adj.Version, adj.Generation).
+		Limit(maxAdjacencyCatchUp+1).
+		Pluck("user_id", &users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) > maxAdjacencyCatchUp {
+		return nil, nil
+	}
+
+	var edges []UserSimilarityEdge
+	if len(users) > 0 {
+		if err := db.Select("user_a, user_b, similarity").
+			Where("version = ? AND (user_a IN ? OR user_b IN ?)", adj.Version, users, users).
+			Find(&edges).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	next := adj.withUserEdges(users, edges)
+	next.Generation = generation
+	return next, nil
+}
+
+// withUserEdges returns a copy of a in which the neighbours of users are
+// exactly the given edges. Lists of users not touched by the change are
+// shared with a.
+func (a *Adjacency) withUserEdges(users []uuid.UUID, edges []UserSimilarityEdge) *Adjacency {
+	next := &Adjacency{
+		Version:    a.Version,
+		Generation: a.Generation,
+		neighbours: make(map[uuid.UUID][]Neighbour, len(a.neighbours)),
+	}
+	for u, list := range a.neighbours {
+		next.neighbours[u] = list
+	}
+
+	changed := make(map[uuid.UUID]bool, len(users))
+	for _, u := range users {
+		changed[u] = true
+	}
+
+	// Users whose lists gain or lose an edge to a changed user.
+	touched := map[uuid.UUID]bool{}
+	for u := range changed {
+		for _, nb := range a.neighbours[u] {
+			touched[nb.UserID] = true
+		}
+		delete(next.neighbours, u)
+	}
+	for _, e := range edges {
+		touched[e.UserA] = true
+		touched[e.UserB] = true
+	}
+
+	for u := range touched {
+		if changed[u] {
+			continue
+		}
+		kept := []Neighbour{}
+		for _, nb := range a.neighbours[u] {
+			if !changed[nb.UserID] {
+				kept = append(kept, nb)
+			}
+		}
+		if len(kept) > 0 {
+			next.neighbours[u] = kept
+		} else {
+			delete(next.neighbours, u)
+		}
+	}
+
+	for _, e := range edges {
+		next.addEdge(e.UserA, e.UserB, e.Similarity)
+	}
+	for u := range touched {
+		next.sortList(u)
+	}
+	for u := range changed {
+		next.sortList(u)
+	}
+	return next
+}
+
+func loadAdjacency(db *gorm.DB, version uint) (*Adjacency, error) {
+	rows, err := db.Model(&UserSimilarityEdge{}).
+		Select("user_a, user_b, similarity").
+		Where("version = ?", version).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adj := &Adjacency{Version: version, neighbours: map[uuid.UUID][]Neighbour{}}
+	for rows.Next() {
+		var a, b uuid.UUID
+		var s float64
+		if err := rows.Scan(&a, &b, &s); err != nil {
+			return nil, err
+		}
+		adj.addEdge(a, b, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	adj.sortNeighbours()
+	return adj, nil
+}
+
+// addEdge records an undirected edge; self-loops are ignored.
+func (a *Adjacency) addEdge(u, v uuid.UUID, similarity float64) {
+	if u == v {
+		return
+	}
+	a.neighbours[u] = append(a.neighbours[u], Neighbour{v, similarity})
+	a.neighbours[v] = append(a.neighbours[v], Neighbour{u, similarity})
+}
+
+// sortNeighbours orders every neighbour list strongest first.
+func (a *Adjacency) sortNeighbours() {
+	for u := range a.neighbours {
+		a.sortList(u)
+	}
+}
+
+func (a *Adjacency) sortList(u uuid.UUID) {
+	list := a.neighbours[u]
+	sort.Slice(list, func(i, j int) bool { return list[i].Similarity > list[j].Similarity })
+}
@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAdjacencyWithUserEdges(t *testing.T) {
+	base := testEdges(
+		[3]float64{1, 2, 0.9},
+		[3]float64{1, 3, 0.5},
+		[3]float64{2, 3, 0.4},
+		[3]float64{4, 5, 0.7},
+	)
+
+	tests := []struct {
+		name  string
+		users []int
+		edges []UserSimilarityEdge
+		want  map[int][]int // testUser numbers, strongest neighbour first
+	}{
+		{
+			name:  "no change",
+			users: nil,
+			want:  map[int][]int{1: {2, 3}, 2: {1, 3}, 3: {1, 2}, 4: {5}, 5: {4}},
+		},
+		{
+			name:  "edges replaced",
+			users: []int{1},
+			edges: testEdges([3]float64{1, 3, 0.95}, [3]float64{1, 4, 0.2}),
+			want:  map[int][]int{1: {3, 4}, 2: {3}, 3: {1, 2}, 4: {5, 1}, 5: {4}},
+		},
+		{
+			name:  "all edges dropped",
+			users: []int{3},
+			want:  map[int][]int{1: {2}, 2: {1}, 4: {5}, 5: {4}},
+		},
+		{
+			name:  "new user",
+			users: []int{6},
+			edges: testEdges([3]float64{5, 6, 0.8}),
+			want:  map[int][]int{1: {2, 3}, 2: {1, 3}, 3: {1, 2}, 4: {5}, 5: {6, 4}, 6: {5}},
+		},
+		{
+			name:  "edge between two changed users kept once",
+			users: []int{4, 5},
+			edges: testEdges([3]float64{4, 5, 0.3}),
+			want:  map[int][]int{1: {2, 3}, 2: {1, 3}, 3: {1, 2}, 4: {5}, 5: {4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adj := testAdjacency(base)
+			before := snapshotNeighbours(adj)
+
+			users := make([]uuid.UUID, len(tt.users))
+			for i, n := range tt.users {
+				users[i] = testUser(n)
+			}
+			got := adj.withUserEdges(users, tt.edges)
+
+			if len(got.neighbours) != len(tt.want) {
+				t.Errorf("%d users with neighbours, want %d", len(got.neighbours), len(tt.want))
+			}
+			for n, want := range tt.want {
+				list := got.Neighbours(testUser(n))
+				if len(list) != len(want) {
+					t.Errorf("user %d: %d neighbours, want %d", n, len(list), len(want))
+					continue
+				}
+				for i, m := range want {
+					if list[i].UserID != testUser(m) {
+						t.Errorf("user %d: neighbour %d = %v, want user %d", n, i, list[i].UserID, m)
+					}
+				}
+			}
+
+			if after := snapshotNeighbours(adj); !equalNeighbours(before, after) {
+				t.Error("original snapshot was modified")
+			}
+		})
+	}
+}
+
+func snapshotNeighbours(adj *Adjacency) map[uuid.UUID][]Neighbour {
+	out := make(map[uuid.UUID][]Neighbour, len(adj.neighbours))
+	for u, list := range adj.neighbours {
+		out[u] = append([]Neighbour(nil), list...)
+	}
+	return out
+}
+
+func equalNeighbours(a, b map[uuid.UUID][]Neighbour) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for u, list := range a {
+		other, ok := b[u]
+		if !ok || len(other) != len(list) {
+			return false
+		}
+		for i := range list {
+			if list[i] != other[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
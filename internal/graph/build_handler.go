@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BuildJobResponse struct {
+	ID          uuid.UUID        `json:"id"`
+	Status      string           `json:"status"`
+	Options     BuildOptions     `json:"options"`
+	TriggeredBy string           `json:"triggered_by"`
+	Progress    ProgressSnapshot `json:"progress"`
+	Version     uint             `json:"version,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	CreatedAt   string           `json:"created_at"`
+	UpdatedAt   string           `json:"updated_at"`
+	CompletedAt string           `json:"completed_at,omitempty"`
+}
+
+func toBuildJobResponse(job BuildJob) BuildJobResponse {
+	var opts BuildOptions
+	_ = json.Unmarshal([]byte(job.Options), &opts)
+
+	resp := BuildJobResponse{
+		ID:          job.ID,
+		Status:      job.Status,
+		Options:     opts,
+		TriggeredBy: job.TriggeredBy,
+		Progress: ProgressSnapshot{
+			Phase:          job.Phase,
+			ProfilesBuilt:  job.ProfilesBuilt,
+			PairsEvaluated: job.PairsEvaluated,
+			EdgesWritten:   job.EdgesWritten,
+		},
+		Version:   job.Version,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: job.UpdatedAt.Format(time.RFC3339),
+	}
+	if job.CompletedAt != nil {
+		resp.CompletedAt = job.CompletedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// StartGraphBuild handles POST /api/graph/builds (and the legacy
+// POST /api/build-graph). The optional JSON body selects the metric,
+// threshold and feature weights; omitted fields default to
+// DefaultBuildOptions. The build runs in the background and the job is
+// returned immediately.
+func StartGraphBuild(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := DefaultBuildOptions()
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&opts); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if _, err := opts.metric(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		triggeredBy := TriggerScheduler
+		if userID, ok := c.Get("user_id"); ok {
+			triggeredBy = "user:" + userID.(uuid.UUID).String()
+		}
+
+		job, running, err := StartBuild(db, opts, triggeredBy)
+		if errors.Is(err, ErrBuildInProgress) {
+			resp := gin.H{"error": err.Error()}
+			if running != uuid.Nil {
+				resp["job_id"] = running
+			}
+			c.JSON(http.StatusConflict, resp)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to start graph build"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"job_id": job.ID,
+			"status": job.Status,
+		})
+	}
+}
+
+// GetGraphBuild handles GET /api/graph/builds/:id.
+func GetGraphBuild(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var job BuildJob
+		if err := db.Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "build not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toBuildJobResponse(job))
+	}
+}
+
+// ListGraphBuilds handles GET /api/graph/builds and returns the 20 most
+// recent builds.
+func ListGraphBuilds(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var jobs []BuildJob
+		if err := db.Order("created_at DESC").Limit(20).Find(&jobs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load builds"})
+			return
+		}
+
+		out := make([]BuildJobResponse, 0, len(jobs))
+		for _, j := range jobs {
+			out = append(out, toBuildJobResponse(j))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"devgraph/internal/cache"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// TriggerScheduler identifies builds started by the periodic rebuild or an
+// external scheduler.
+const TriggerScheduler = "scheduler"
+
+// buildFlushInterval is how often a running build writes its progress and
+// renews the lock.
+const buildFlushInterval = 2 * time.Second
+
+// ErrBuildInProgress is returned by StartBuild while another build holds
+// the lock.
+var ErrBuildInProgress = errors.New("a graph build is already running")
+
+// ErrBuildLockLost fails a build whose lock expired or was taken over, so
+// two builds never activate versions concurrently.
+var ErrBuildLockLost = errors.New("graph build lock lost")
+
+// StartBuild queues a graph build with opts and returns its job
+// immediately. The build runs in the background under the Redis build lock;
+// if another build holds it, ErrBuildInProgress is returned together with
+// the ID of the running job.
+func StartBuild(db *gorm.DB, opts BuildOptions, triggeredBy string) (*BuildJob, uuid.UUID, error) {
+	if _, err := opts.metric(); err != nil {
+		return nil, uuid.Nil, err
+	}
+	encoded, err := json.Marshal(opts)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	failInterruptedBuilds(db)
+
+	job := &BuildJob{
+		ID:          uuid.New(),
+		Status:      BuildPending,
+		Options:     string(encoded),
+		TriggeredBy: triggeredBy,
+	}
+
+	rdb := cache.NewRedisClient()
+	acquired, err := acquireBuildLock(rdb, job.ID.String())
+	if err != nil {
+		rdb.Close()
+		return nil, uuid.Nil, err
+	}
+	if !acquired {
+		defer rdb.Close()
+		holder, err := buildLockHolder(rdb)
+		if err != nil {
+			return nil, uuid.Nil, ErrBuildInProgress
+		}
+		running, _ := uuid.Parse(holder)
+		return nil, running, ErrBuildInProgress
+	}
+
+	if err := db.Create(job).Error; err != nil {
+		releaseBuildLock(rdb, job.ID.String())
+		rdb.Close()
+		return nil, uuid.Nil, err
+	}
+
+	go runBuildJob(db, rdb, *job, opts)
+	return job, uuid.Nil, nil
+}
+
+func runBuildJob(db *gorm.DB, rdb *redis.Client, job BuildJob, opts BuildOptions) {
+	defer rdb.Close()
+	defer func() {
+		if err := releaseBuildLock(rdb, job.ID.String()); err != nil {
+			log.Println("failed to release graph build lock:", err)
+		}
+	}()
+
+	db.Model(&BuildJob{}).Where("id = ?", job.ID).Update("status", BuildRunning)
+
+	progress := &BuildProgress{}
+	stop := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ticker := time.NewTicker(buildFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				saveProgress(db, job.ID, progress.Snapshot(), nil)
+				renewed, err := renewBuildLock(rdb, job.ID.String())
+				if err != nil {
+					log.Println("failed to renew graph build lock:", err)
+				} else if !renewed {
+					log.Println("graph build lock lost, aborting build", job.ID)
+					progress.abort()
+					return
+				}
+			}
+		}
+	}()
+
+	version, err := rebuild(db, opts, progress)
+	close(stop)
+	<-flushed
+
+	now := time.Now()
+	final := map[string]interface{}{
+		"status":       BuildDone,
+		"version":      version,
+		"completed_at": now,
+	}
+	if err != nil {
+		log.Println("graph build failed:", err)
+		final["status"] = BuildFailed
+		final["error"] = err.Error()
+	}
+	saveProgress(db, job.ID, progress.Snapshot(), final)
+}
+
+// saveProgress writes the counters of snapshot, plus any extra columns, to
+// the job row.
+func saveProgress(db *gorm.DB, jobID uuid.UUID, snapshot ProgressSnapshot, extra map[string]interface{}) {
+	updates := map[string]interface{}{
+		"phase":           snapshot.Phase,
+		"profiles_built":  snapshot.ProfilesBuilt,
+		"pairs_evaluated": snapshot.PairsEvaluated,
+		"edges_written":   snapshot.EdgesWritten,
+		"updated_at":      time.Now(),
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	if err := db.Model(&BuildJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		log.Println("failed to save graph build progress:", err)
+	}
+}
+
+// failInterruptedBuilds marks jobs that stopped reporting progress for
+// longer than the lock TTL as failed; their process died mid-build and the
+// lock has expired.
+func failInterruptedBuilds(db *gorm.DB) {
+	now := time.Now()
+	db.Model(&BuildJob{}).
+		Where("status IN ? AND updated_at < ?", []string{BuildPending, BuildRunning}, now.Add(-buildLockTTL)).
+		Updates(map[string]interface{}{
+			"status":       BuildFailed,
+			"error":        "build interrupted",
+			"completed_at": now,
+		})
+}
@@ -0,0 +1,62 @@
+package graph
+
+// build_lock.go — cluster-wide mutual exclusion for graph builds
+//
+// A build holds the Redis key buildLockKey, set with NX to the job ID and a
+// TTL. The running job renews the TTL while it makes progress, so a crashed
+// process releases the lock after at most buildLockTTL. Renewal and release
+// only touch the key if it still holds the caller's job ID; a build whose
+// renewal finds another holder has lost the lock and aborts.
+
+import (
+	"time"
+
+	"devgraph/internal/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	buildLockKey = "graph:build:lock"
+	buildLockTTL = 5 * time.Minute
+)
+
+var (
+	renewScript = redis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+		end
+		return 0`)
+
+	releaseScript = redis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("DEL", KEYS[1])
+		end
+		return 0`)
+)
+
+// acquireBuildLock takes the lock for jobID. It returns false without error
+// when another build holds it.
+func acquireBuildLock(rdb *redis.Client, jobID string) (bool, error) {
+	return rdb.SetNX(cache.Ctx, buildLockKey, jobID, buildLockTTL).Result()
+}
+
+// buildLockHolder returns the job ID holding the lock, or "" if it is free.
+func buildLockHolder(rdb *redis.Client) (string, error) {
+	holder, err := rdb.Get(cache.Ctx, buildLockKey).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return holder, err
+}
+
+// renewBuildLock extends the lock of jobID. It returns false without error
+// when the lock has expired or is held by another job.
+func renewBuildLock(rdb *redis.Client, jobID string) (bool, error) {
+	renewed, err := renewScript.Run(cache.Ctx, rdb, []string{buildLockKey}, jobID, buildLockTTL.Milliseconds()).Int()
+	return renewed == 1, err
+}
+
+func releaseBuildLock(rdb *redis.Client, jobID string) error {
+	return releaseScript.Run(cache.Ctx, rdb, []string{buildLockKey}, jobID).Err()
+}
@@ -1,39 +1,143 @@
 package graph
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 func BuildUserProfiles(db *gorm.DB) ([]UserPatternProfile, error) {
+	return loadProfiles(db, nil, DefaultFeatureWeights())
+}
+
+// BuildUserProfilesWith builds every user's profile with the given feature
+// weights.
+func BuildUserProfilesWith(db *gorm.DB, weights FeatureWeights) ([]UserPatternProfile, error) {
+	return loadProfiles(db, nil, weights)
+}
+
+// BuildProfilesFor builds profiles for the given users only. Users without
+// any submission are omitted.
+func BuildProfilesFor(db *gorm.DB, userIDs []string, weights FeatureWeights) ([]UserPatternProfile, error) {
+	if len(userIDs) == 0 {
+		return []UserPatternProfile{}, nil
+	}
+	return loadProfiles(db, userIDs, weights)
+}
+
+// loadProfiles builds profiles for userIDs, or for every user when userIDs
+// is nil.
+func loadProfiles(db *gorm.DB, userIDs []string, weights FeatureWeights) ([]UserPatternProfile, error) {
+	filter := ""
+	args := []interface{}{}
+	if userIDs != nil {
+		filter = "WHERE cs.user_id IN ?"
+		args = append(args, userIDs)
+	}
+
+	now := time.Now()
+	counts := map[string]map[string]int{}
+	features := map[string]map[string]float64{}
+
+	add := func(userID, key string, weight float64, createdAt time.Time, count bool) {
+		if _, ok := counts[userID]; !ok {
+			counts[userID] = map[string]int{}
+			features[userID] = map[string]float64{}
+		}
+		if count {
+			counts[userID][key]++
+		}
+		if weight > 0 {
+			features[userID][key] += weight * weights.decay(createdAt, now)
+		}
+	}
+
 	rows, err := db.Raw(`
-		SELECT cs.user_id, ap.name
+		SELECT cs.user_id, ap.name, cs.created_at
 		FROM code_submissions cs
 		JOIN submission_patterns sp ON cs.id = sp.submission_id
 		JOIN algorithm_patterns ap ON sp.pattern_id = ap.id
-	`).Rows()
+		`+filter, args...).Rows()
 
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	profiles := map[string]map[string]int{}
-
 	for rows.Next() {
 		var userID string
 		var pattern string
+		var createdAt time.Time
 
-		rows.Scan(&userID, &pattern)
+		if err := rows.Scan(&userID, &pattern, &createdAt); err != nil {
+			return nil, err
+		}
+		add(userID, pattern, weights.Patterns, createdAt, true)
+	}
+
+	// Solving the same problem is a strong similarity signal, so each solved
+	// problem is counted as an extra profile feature alongside the patterns.
+	problemFilter := "WHERE cs.problem_id IS NOT NULL"
+	if userIDs != nil {
+		problemFilter += " AND cs.user_id IN ?"
+	}
 
-		if _, ok := profiles[userID]; !ok {
-			profiles[userID] = map[string]int{}
+	solved, err := db.Raw(`
+		SELECT cs.user_id, cs.problem_id, MAX(cs.created_at)
+		FROM code_submissions cs
+		`+problemFilter+`
+		GROUP BY cs.user_id, cs.problem_id`, args...).Rows()
+
+	if err != nil {
+		return nil, err
+	}
+	defer solved.Close()
+
+	for solved.Next() {
+		var userID string
+		var problemID string
+		var createdAt time.Time
+
+		if err := solved.Scan(&userID, &problemID, &createdAt); err != nil {
+			return nil, err
+		}
+		add(userID, ProblemFeaturePrefix+problemID, weights.Problems, createdAt, true)
+	}
+
+	// Complexity-class distribution and language mix: one unit per
+	// submission, so both groups are on the same scale as pattern counts.
+	mix, err := db.Raw(`
+		SELECT cs.user_id, cs.language, COALESCE(ca.time_complexity, ''), cs.created_at
+		FROM code_submissions cs
+		LEFT JOIN code_analyses ca ON ca.submission_id = cs.id
+		`+filter, args...).Rows()
+
+	if err != nil {
+		return nil, err
+	}
+	defer mix.Close()
+
+	for mix.Next() {
+		var userID string
+		var language string
+		var complexity string
+		var createdAt time.Time
+
+		if err := mix.Scan(&userID, &language, &complexity, &createdAt); err != nil {
+			return nil, err
+		}
+		add(userID, LanguageFeaturePrefix+language, weights.Language, createdAt, false)
+		if complexity != "" {
+			add(userID, ComplexityFeaturePrefix+complexity, weights.Complexity, createdAt, false)
 		}
-		profiles[userID][pattern]++
 	}
 
 	result := []UserPatternProfile{}
-	for uid, patterns := range profiles {
+	for uid, patterns := range counts {
 		result = append(result, UserPatternProfile{
 			UserID:   uid,
 			Patterns: patterns,
+			Features: features[uid],
 		})
 	}
 
@@ -0,0 +1,149 @@
+package graph
+
+// centrality.go — influence scores of a graph version
+//
+// Two measures are stored per user for every build:
+//
+//   - Degree and weighted degree (the sum of edge similarities): how many
+//     developers someone resembles and how strongly.
+//   - Weighted PageRank: a random walk that follows each edge with
+//     probability proportional to its similarity and restarts uniformly with
+//     probability 1-d. Users similar to other well-connected users score
+//     higher than users with many weak, peripheral links.
+//
+// Scores are computed from the edges being persisted, so they always match
+// the version they are stored with.
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+)
+
+// UserCentrality holds one user's influence scores within a graph version.
+// PageRank values of a version sum to 1.
+type UserCentrality struct {
+	Version        uint      `gorm:"primaryKey;autoIncrement:false"`
+	UserID         uuid.UUID `gorm:"primaryKey"`
+	PageRank       float64   `gorm:"not null;index"`
+	Degree         int       `gorm:"not null"`
+	WeightedDegree float64   `gorm:"not null"`
+}
+
+// ComputeCentrality returns degree, weighted degree and weighted PageRank
+// for every user appearing in edges.
+func ComputeCentrality(edges []UserSimilarityEdge) map[uuid.UUID]UserCentrality {
+	index := map[uuid.UUID]int{}
+	nodes := []uuid.UUID{}
+	node := func(u uuid.UUID) int {
+		i, ok := index[u]
+		if !ok {
+			i = len(nodes)
+			index[u] = i
+			nodes = append(nodes, u)
+		}
+		return i
+	}
+
+	type arc struct {
+		to     int
+		weight float64
+	}
+	for _, e := range edges {
+		node(e.UserA)
+		node(e.UserB)
+	}
+
+	adj := make([][]arc, len(nodes))
+	for _, e := range edges {
+		a, b := index[e.UserA], index[e.UserB]
+		if a == b || e.Similarity <= 0 {
+			continue
+		}
+		adj[a] = append(adj[a], arc{b, e.Similarity})
+		adj[b] = append(adj[b], arc{a, e.Similarity})
+	}
+
+	n := len(nodes)
+	if n == 0 {
+		return map[uuid.UUID]UserCentrality{}
+	}
+
+	strength := make([]float64, n)
+	for i, arcs := range adj {
+		for _, a := range arcs {
+			strength[i] += a.weight
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+
+	for iter := 0; iter < pageRankIterations; iter++ {
+		// Mass of nodes without edges is spread uniformly, as for restarts.
+		dangling := 0.0
+		for i := range rank {
+			if strength[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/float64(n) + pageRankDamping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, arcs := range adj {
+			if strength[i] == 0 {
+				continue
+			}
+			share := pageRankDamping * rank[i] / strength[i]
+			for _, a := range arcs {
+				next[a.to] += share * a.weight
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	out := make(map[uuid.UUID]UserCentrality, n)
+	for i, u := range nodes {
+		out[u] = UserCentrality{
+			UserID:         u,
+			PageRank:       rank[i],
+			Degree:         len(adj[i]),
+			WeightedDegree: strength[i],
+		}
+	}
+	return out
+}
+
+// storeCentrality computes and stores the influence scores of a version.
+func storeCentrality(tx *gorm.DB, version uint, edges []UserSimilarityEdge) error {
+	scores := ComputeCentrality(edges)
+	if len(scores) == 0 {
+		return nil
+	}
+
+	rows := make([]UserCentrality, 0, len(scores))
+	for _, c := range scores {
+		c.Version = version
+		rows = append(rows, c)
+	}
+	return tx.CreateInBatches(rows, 1000).Error
+}
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeCentrality(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    []UserSimilarityEdge
+		degree   map[int]int
+		weighted map[int]float64
+		ranked   []int // testUser numbers by strictly decreasing PageRank
+	}{
+		{
+			name:     "no edges",
+			edges:    nil,
+			degree:   map[int]int{},
+			weighted: map[int]float64{},
+		},
+		{
+			name:     "single edge",
+			edges:    testEdges([3]float64{1, 2, 0.5}),
+			degree:   map[int]int{1: 1, 2: 1},
+			weighted: map[int]float64{1: 0.5, 2: 0.5},
+		},
+		{
+			name:     "star center ranks first",
+			edges:    testEdges([3]float64{1, 2, 0.4}, [3]float64{1, 3, 0.4}, [3]float64{1, 4, 0.4}),
+			degree:   map[int]int{1: 3, 2: 1, 3: 1, 4: 1},
+			weighted: map[int]float64{1: 1.2, 2: 0.4, 3: 0.4, 4: 0.4},
+			ranked:   []int{1, 2},
+		},
+		{
+			name:     "stronger edges attract more rank",
+			edges:    testEdges([3]float64{1, 2, 0.9}, [3]float64{1, 3, 0.1}),
+			degree:   map[int]int{1: 2, 2: 1, 3: 1},
+			weighted: map[int]float64{1: 1.0, 2: 0.9, 3: 0.1},
+			ranked:   []int{1, 2, 3},
+		},
+		{
+			name:     "self loops and zero similarity ignored",
+			edges:    testEdges([3]float64{1, 1, 0.8}, [3]float64{1, 2, 0}, [3]float64{2, 3, 0.6}),
+			degree:   map[int]int{1: 0, 2: 1, 3: 1},
+			weighted: map[int]float64{1: 0, 2: 0.6, 3: 0.6},
+			ranked:   []int{2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeCentrality(tt.edges)
+			if len(got) != len(tt.degree) {
+				t.Fatalf("%d users scored, want %d", len(got), len(tt.degree))
+			}
+
+			total := 0.0
+			for n, degree := range tt.degree {
+				c, ok := got[testUser(n)]
+				if !ok {
+					t.Fatalf("user %d missing", n)
+				}
+				if c.UserID != testUser(n) {
+					t.Errorf("user %d: UserID = %v", n, c.UserID)
+				}
+				if c.Degree != degree {
+					t.Errorf("user %d: degree = %d, want %d", n, c.Degree, degree)
+				}
+				if math.Abs(c.WeightedDegree-tt.weighted[n]) > 1e-9 {
+					t.Errorf("user %d: weighted degree = %v, want %v", n, c.WeightedDegree, tt.weighted[n])
+				}
+				total += c.PageRank
+			}
+			if len(got) > 0 && math.Abs(total-1) > 1e-6 {
+				t.Errorf("PageRank sums to %v, want 1", total)
+			}
+
+			for i := 1; i < len(tt.ranked); i++ {
+				hi, lo := got[testUser(tt.ranked[i-1])], got[testUser(tt.ranked[i])]
+				if hi.PageRank <= lo.PageRank {
+					t.Errorf("PageRank of %d (%v) not above %d (%v)", tt.ranked[i-1], hi.PageRank, tt.ranked[i], lo.PageRank)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeCentralitySymmetric(t *testing.T) {
+	// Users in symmetric positions of a 4-cycle score the same.
+	got := ComputeCentrality(testEdges(
+		[3]float64{1, 2, 0.5}, [3]float64{2, 3, 0.5}, [3]float64{3, 4, 0.5}, [3]float64{4, 1, 0.5},
+	))
+	for n := 1; n <= 4; n++ {
+		if pr := got[testUser(n)].PageRank; math.Abs(pr-0.25) > 1e-6 {
+			t.Errorf("user %d: PageRank = %v, want 0.25", n, pr)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package graph
+
+// community.go — community detection over a graph version
+//
+// Communities are found with weighted label propagation (Raghavan, Albert
+// and Kumara, 2007): every user starts in its own community and repeatedly
+// adopts the label carrying the largest total edge weight among its
+// neighbours until no label changes. It is near-linear in the edge count,
+// needs no resolution parameter and produces tight groups that work well as
+// study groups.
+//
+// Visiting order and ties are resolved deterministically so that rebuilding
+// the same edges yields the same communities.
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// labelPropagationRounds bounds the number of sweeps; label propagation
+	// normally converges within a handful.
+	labelPropagationRounds = 50
+
+	// communityTopPatterns is the number of patterns kept to describe a
+	// community.
+	communityTopPatterns = 5
+)
+
+// Community describes one detected community of a graph version.
+// TopPatterns is a comma-separated list of the patterns most used by its
+// members, most frequent first.
+type Community struct {
+	Version     uint `gorm:"primaryKey;autoIncrement:false"`
+	CommunityID int  `gorm:"primaryKey;autoIncrement:false"`
+	Size        int  `gorm:"not null"`
+	TopPatterns string
+	CreatedAt   time.Time
+}
+
+// UserCommunity assigns a user to a community within a graph version. Users
+// without any similarity edge belong to no community.
+type UserCommunity struct {
+	Version     uint      `gorm:"primaryKey;autoIncrement:false"`
+	UserID      uuid.UUID `gorm:"primaryKey"`
+	CommunityID int       `gorm:"not null;index"`
+}
+
+// DetectCommunities partitions the users appearing in edges into
+// communities. Community IDs are numbered from 1 by decreasing size.
+func DetectCommunities(edges []UserSimilarityEdge) map[uuid.UUID]int {
+	index := map[uuid.UUID]int{}
+	nodes := []uuid.UUID{}
+	for _, e := range edges {
+		for _, u := range []uuid.UUID{e.UserA, e.UserB} {
+			if _, ok := index[u]; !ok {
+				index[u] = len(nodes)
+				nodes = append(nodes, u)
+			}
+		}
+	}
+
+	// Number nodes in UUID order so labels do not depend on edge order.
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].String() < nodes[j].String() })
+	for i, u := range nodes {
+		index[u] = i
+	}
+
+	type neighbour struct {
+		node   int
+		weight float64
+	}
+	adj := make([][]neighbour, len(nodes))
+	for _, e := range edges {
+		a, b := index[e.UserA], index[e.UserB]
+		if a == b {
+			continue
+		}
+		adj[a] = append(adj[a], neighbour{b, e.Similarity})
+		adj[b] = append(adj[b], neighbour{a, e.Similarity})
+	}
+
+	labels := make([]int, len(nodes))
+	for i := range labels {
+		labels[i] = i
+	}
+
+	// Asynchronous updates in a fixed pseudo-random order avoid the label
+	// oscillation of synchronous sweeps on bipartite subgraphs.
+	order := make([]int, len(nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return splitmix(uint64(order[i])) < splitmix(uint64(order[j]))
+	})
+
+	weights := map[int]float64{}
+	for round := 0; round < labelPropagationRounds; round++ {
+		changed := false
+		for _, n := range order {
+			if len(adj[n]) == 0 {
+				continue
+			}
+			for k := range weights {
+				delete(weights, k)
+			}
+			for _, nb := range adj[n] {
+				weights[labels[nb.node]] += nb.weight
+			}
+
+			// Keep the current label on ties so the sweep terminates; among
+			// other tied labels prefer the smallest.
+			best, bestWeight := labels[n], weights[labels[n]]
+			for label, w := range weights {
+				if w > bestWeight || (w == bestWeight && label < best && best != labels[n]) {
+					best, bestWeight = label, w
+				}
+			}
+			if best != labels[n] {
+				labels[n] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	sizes := map[int]int{}
+	for _, l := range labels {
+		sizes[l]++
+	}
+	distinct := make([]int, 0, len(sizes))
+	for l := range sizes {
+		distinct = append(distinct, l)
+	}
+	sort.Slice(distinct, func(i, j int) bool {
+		if sizes[distinct[i]] != sizes[distinct[j]] {
+			return sizes[distinct[i]] > sizes[distinct[j]]
+		}
+		return distinct[i] < distinct[j]
+	})
+	renumber := make(map[int]int, len(distinct))
+	for i, l := range distinct {
+		renumber[l] = i + 1
+	}
+
+	out := make(map[uuid.UUID]int, len(nodes))
+	for i, u := range nodes {
+		out[u] = renumber[labels[i]]
+	}
+	return out
+}
+
+// storeCommunities detects the communities of a version's edges and stores
+// the assignments together with each community's size and top patterns.
+func storeCommunities(tx *gorm.DB, version uint, edges []UserSimilarityEdge) error {
+	assignment := DetectCommunities(edges)
+	if len(assignment) == 0 {
+		return nil
+	}
+
+	sizes := map[int]int{}
+	rows := make([]UserCommunity, 0, len(assignment))
+	for u, c := range assignment {
+		sizes[c]++
+		rows = append(rows, UserCommunity{Version: version, UserID: u, CommunityID: c})
+	}
+	if err := tx.CreateInBatches(rows, 1000).Error; err != nil {
+		return err
+	}
+
+	top, err := communityPatterns(tx, version)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	communities := make([]Community, 0, len(sizes))
+	for c, size := range sizes {
+		communities = append(communities, Community{
+			Version:     version,
+			CommunityID: c,
+			Size:        size,
+			TopPatterns: strings.Join(top[c], ","),
+			CreatedAt:   now,
+		})
+	}
+	return tx.CreateInBatches(communities, 1000).Error
+}
+
+// communityPatterns returns the most used patterns of each community of a
+// version, counting one use per member submission.
+func communityPatterns(db *gorm.DB, version uint) (map[int][]string, error) {
+	rows, err := db.Raw(`
+		SELECT uc.community_id, ap.name, COUNT(*) AS uses
+		FROM user_communities uc
+		JOIN code_submissions cs ON cs.user_id = uc.user_id
+		JOIN submission_patterns sp ON sp.submission_id = cs.id
+		JOIN algorithm_patterns ap ON ap.id = sp.pattern_id
+		WHERE uc.version = ?
+		GROUP BY uc.community_id, ap.name
+		ORDER BY uc.community_id, uses DESC, ap.name
+	`, version).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	top := map[int][]string{}
+	for rows.Next() {
+		var community int
+		var name string
+		var uses int
+		if err := rows.Scan(&community, &name, &uses); err != nil {
+			return nil, err
+		}
+		if len(top[community]) < communityTopPatterns {
+			top[community] = append(top[community], name)
+		}
+	}
+	return top, rows.Err()
+}
@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommunityResponse struct {
+	ID          int      `json:"id"`
+	Version     uint     `json:"version"`
+	Size        int      `json:"size"`
+	TopPatterns []string `json:"top_patterns"`
+}
+
+type CommunityMember struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatar_url"`
+}
+
+func toCommunityResponse(c Community) CommunityResponse {
+	patterns := []string{}
+	if c.TopPatterns != "" {
+		patterns = strings.Split(c.TopPatterns, ",")
+	}
+	return CommunityResponse{
+		ID:          c.CommunityID,
+		Version:     c.Version,
+		Size:        c.Size,
+		TopPatterns: patterns,
+	}
+}
+
+// ListCommunities handles GET /api/communities. It lists the communities of
+// the active graph version, largest first.
+func ListCommunities(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		var communities []Community
+		if err := db.Where("version = ?", version).
+			Order("size DESC, community_id").
+			Find(&communities).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load communities"})
+			return
+		}
+
+		out := make([]CommunityResponse, 0, len(communities))
+		for _, cm := range communities {
+			out = append(out, toCommunityResponse(cm))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// GetUserCommunity handles GET /api/community (the caller's community) and
+// GET /api/users/:id/community.
+func GetUserCommunity(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+		if param := c.Param("id"); param != "" {
+			id, err := uuid.Parse(param)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+				return
+			}
+			userID = id
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		var membership UserCommunity
+		err = db.Where("version = ? AND user_id = ?", version, userID).Take(&membership).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user is not in any community"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load community"})
+			return
+		}
+
+		var community Community
+		if err := db.Where("version = ? AND community_id = ?", version, membership.CommunityID).
+			Take(&community).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load community"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toCommunityResponse(community))
+	}
+}
+
+// GetCommunityMembers handles GET /api/communities/:id/members.
+func GetCommunityMembers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid community id"})
+			return
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		var community Community
+		err = db.Where("version = ? AND community_id = ?", version, id).Take(&community).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "community not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load community"})
+			return
+		}
+
+		members := []CommunityMember{}
+		if err := db.Raw(`
+			SELECT u.id AS user_id, u.username, u.avatar_url
+			FROM user_communities uc
+			JOIN users u ON u.id = uc.user_id
+			WHERE uc.version = ? AND uc.community_id = ?
+			ORDER BY u.username
+		`, version, id).Scan(&members).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load members"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"community": toCommunityResponse(community),
+			"members":   members,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDetectCommunities(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []UserSimilarityEdge
+		want  map[int]int // testUser number → community
+	}{
+		{
+			name:  "no edges",
+			edges: nil,
+			want:  map[int]int{},
+		},
+		{
+			name:  "single edge",
+			edges: testEdges([3]float64{1, 2, 0.5}),
+			want:  map[int]int{1: 1, 2: 1},
+		},
+		{
+			name: "two triangles joined by a weak edge",
+			edges: testEdges(
+				[3]float64{1, 2, 0.9}, [3]float64{2, 3, 0.9}, [3]float64{1, 3, 0.9},
+				[3]float64{4, 5, 0.8}, [3]float64{5, 6, 0.8}, [3]float64{4, 6, 0.8},
+				[3]float64{3, 4, 0.1},
+			),
+			want: map[int]int{1: 1, 2: 1, 3: 1, 4: 2, 5: 2, 6: 2},
+		},
+		{
+			name: "larger community numbered first",
+			edges: testEdges(
+				[3]float64{1, 2, 0.7}, [3]float64{2, 3, 0.7}, [3]float64{1, 3, 0.7},
+				[3]float64{4, 5, 0.7}, [3]float64{5, 6, 0.7}, [3]float64{6, 7, 0.7},
+				[3]float64{4, 6, 0.7}, [3]float64{4, 7, 0.7}, [3]float64{5, 7, 0.7},
+			),
+			want: map[int]int{4: 1, 5: 1, 6: 1, 7: 1, 1: 2, 2: 2, 3: 2},
+		},
+		{
+			name: "disconnected pairs of equal size",
+			edges: testEdges(
+				[3]float64{3, 4, 0.5},
+				[3]float64{1, 2, 0.5},
+			),
+			want: map[int]int{1: 1, 2: 1, 3: 2, 4: 2},
+		},
+		{
+			name:  "self-loop is ignored",
+			edges: testEdges([3]float64{1, 1, 1}, [3]float64{2, 3, 0.4}),
+			want:  map[int]int{2: 1, 3: 1, 1: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make(map[uuid.UUID]int, len(tt.want))
+			for n, c := range tt.want {
+				want[testUser(n)] = c
+			}
+			if got := DetectCommunities(tt.edges); !reflect.DeepEqual(got, want) {
+				t.Errorf("communities = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDetectCommunitiesIgnoresEdgeOrder(t *testing.T) {
+	edges := testEdges(
+		[3]float64{1, 2, 0.9}, [3]float64{2, 3, 0.6}, [3]float64{3, 4, 0.2},
+		[3]float64{4, 5, 0.9}, [3]float64{5, 6, 0.6}, [3]float64{6, 1, 0.2},
+		[3]float64{2, 5, 0.3}, [3]float64{1, 3, 0.4},
+	)
+	want := DetectCommunities(edges)
+
+	reversed := make([]UserSimilarityEdge, len(edges))
+	for i, e := range edges {
+		reversed[len(edges)-1-i] = UserSimilarityEdge{UserA: e.UserB, UserB: e.UserA, Similarity: e.Similarity}
+	}
+	if got := DetectCommunities(reversed); !reflect.DeepEqual(got, want) {
+		t.Errorf("reordered edges give %v, want %v", got, want)
+	}
+}
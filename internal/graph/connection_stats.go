@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"net/http"
+
+	"devgraph/internal/connection"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// connectionBuckets is the number of equal-width similarity buckets.
+const connectionBuckets = 10
+
+type SimilarityBucket struct {
+	Min            float64 `json:"min"`
+	Max            float64 `json:"max"`
+	Edges          int     `json:"edges"`
+	Connected      int     `json:"connected"`
+	ConnectionRate float64 `json:"connection_rate"`
+}
+
+// GetConnectionStats handles GET /api/graph/connection-stats.
+//
+// Compares computed similarity with social connections: for each
+// similarity bucket of the active version it reports how many linked pairs
+// are also connected. Connections between users without a similarity edge
+// are counted separately as unlinked.
+func GetConnectionStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		var rows []struct {
+			Bucket    int
+			Edges     int
+			Connected int
+		}
+		if err := db.Raw(`
+			SELECT LEAST(FLOOR(e.similarity * ?), ?) AS bucket,
+				COUNT(*) AS edges,
+				COUNT(cr.id) AS connected
+			FROM user_similarity_edges e
+			LEFT JOIN connection_requests cr ON cr.status = ? AND (
+				(cr.requester_id = e.user_a AND cr.addressee_id = e.user_b) OR
+				(cr.requester_id = e.user_b AND cr.addressee_id = e.user_a))
+			WHERE e.version = ?
+			GROUP BY 1
+		`, connectionBuckets, connectionBuckets-1, connection.StatusAccepted, version).Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load connection stats"})
+			return
+		}
+
+		var connections int64
+		if err := db.Model(&connection.ConnectionRequest{}).
+			Where("status = ?", connection.StatusAccepted).
+			Count(&connections).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count connections"})
+			return
+		}
+
+		buckets := make([]SimilarityBucket, connectionBuckets)
+		for i := range buckets {
+			buckets[i].Min = float64(i) / connectionBuckets
+			buckets[i].Max = float64(i+1) / connectionBuckets
+		}
+		linked := 0
+		for _, r := range rows {
+			if r.Bucket < 0 || r.Bucket >= connectionBuckets {
+				continue
+			}
+			b := &buckets[r.Bucket]
+			b.Edges = r.Edges
+			b.Connected = r.Connected
+			if r.Edges > 0 {
+				b.ConnectionRate = float64(r.Connected) / float64(r.Edges)
+			}
+			linked += r.Connected
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"version":     version,
+			"buckets":     buckets,
+			"connections": connections,
+			"unlinked":    int(connections) - linked,
+		})
+	}
+}
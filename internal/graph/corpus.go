@@ -0,0 +1,126 @@
+package graph
+
+// corpus.go — per-version corpus statistics
+//
+// The corpus-weighted metrics (TF-IDF, BM25) need population statistics:
+// how many profiles use each feature and the average profile mass. A full
+// build derives them from every profile and stores them with the version it
+// creates, so incremental updates can score one user against the same
+// statistics without loading the whole population again. The statistics of
+// a version never change and are cached in memory once read.
+
+import (
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// CorpusStats are the population statistics a SimilarityMetric is fitted
+// with.
+type CorpusStats struct {
+	Profiles          int
+	AvgMass           float64
+	DocumentFrequency map[string]int
+}
+
+// NewCorpusStats derives the statistics of a population of profiles.
+func NewCorpusStats(profiles []UserPatternProfile) CorpusStats {
+	stats := CorpusStats{
+		Profiles:          len(profiles),
+		DocumentFrequency: map[string]int{},
+	}
+
+	total := 0.0
+	for _, p := range profiles {
+		v := p.Vector()
+		for k, x := range v {
+			if x > 0 {
+				stats.DocumentFrequency[k]++
+			}
+		}
+		total += vectorMass(v)
+	}
+	if len(profiles) > 0 {
+		stats.AvgMass = total / float64(len(profiles))
+	}
+	return stats
+}
+
+// FeatureFrequency is the number of profiles of a version using a feature.
+type FeatureFrequency struct {
+	Version  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Feature  string `gorm:"primaryKey"`
+	Profiles int    `gorm:"not null"`
+}
+
+// storeCorpusStats stores the document frequencies of a version. The
+// profile count and average mass are recorded on the GraphVersion row.
+func storeCorpusStats(tx *gorm.DB, version uint, stats CorpusStats) error {
+	rows := make([]FeatureFrequency, 0, len(stats.DocumentFrequency))
+	for feature, n := range stats.DocumentFrequency {
+		rows = append(rows, FeatureFrequency{Version: version, Feature: feature, Profiles: n})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(rows, persistBatchSize).Error
+}
+
+var corpusCache struct {
+	sync.Mutex
+	version uint
+	stats   *CorpusStats
+}
+
+// ActiveCorpusStats returns the corpus statistics of the active graph
+// version. Before the first versioned build, or for versions built before
+// statistics were stored, they are derived once from the population with
+// weights and cached until the active version changes.
+func ActiveCorpusStats(db *gorm.DB, weights FeatureWeights) (CorpusStats, error) {
+	var v GraphVersion
+	err := db.Where("status = ?", VersionActive).Order("id DESC").Take(&v).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return CorpusStats{}, err
+	}
+
+	corpusCache.Lock()
+	defer corpusCache.Unlock()
+
+	if corpusCache.stats != nil && corpusCache.version == v.ID {
+		return *corpusCache.stats, nil
+	}
+
+	var stats CorpusStats
+	if v.ID > 0 && v.Profiles > 0 {
+		stats, err = loadCorpusStats(db, v)
+	} else {
+		var profiles []UserPatternProfile
+		profiles, err = BuildUserProfilesWith(db, weights)
+		stats = NewCorpusStats(profiles)
+	}
+	if err != nil {
+		return CorpusStats{}, err
+	}
+
+	corpusCache.version = v.ID
+	corpusCache.stats = &stats
+	return stats, nil
+}
+
+func loadCorpusStats(db *gorm.DB, v GraphVersion) (CorpusStats, error) {
+	var rows []FeatureFrequency
+	if err := db.Where("version = ?", v.ID).Find(&rows).Error; err != nil {
+		return CorpusStats{}, err
+	}
+
+	stats := CorpusStats{
+		Profiles:          v.Profiles,
+		AvgMass:           v.AvgFeatureMass,
+		DocumentFrequency: make(map[string]int, len(rows)),
+	}
+	for _, r := range rows {
+		stats.DocumentFrequency[r.Feature] = r.Profiles
+	}
+	return stats, nil
+}
@@ -0,0 +1,265 @@
+package graph
+
+// export.go — streaming export of the active similarity graph
+//
+// WriteGraph streams nodes and then edges straight from database cursors
+// into one of the format writers in export_format.go. Only the current row
+// is held in memory; node attributes (top patterns) come from a second
+// cursor ordered by the same key and merged alongside the node cursor.
+//
+// An ego filter restricts the export to the users within Radius hops of one
+// user. The neighbourhood and its edges come from the shared Adjacency
+// snapshot (see query.go), so only node attributes are read from the
+// database.
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// exportTopPatterns is the number of patterns listed per node.
+const exportTopPatterns = 3
+
+// ExportFilter restricts which part of the active graph is exported.
+// Ego == nil exports the whole graph; Radius is at most MaxQueryHops.
+type ExportFilter struct {
+	MinSimilarity float64
+	Ego           *uuid.UUID
+	Radius        int
+}
+
+type ExportNode struct {
+	ID          uuid.UUID
+	Username    string
+	TopPatterns []string
+	Community   int // 0 when the user is in no community
+}
+
+type ExportEdge struct {
+	Source     uuid.UUID
+	Target     uuid.UUID
+	Similarity float64
+}
+
+// userSchema lists the attributes of user graph exports.
+var userSchema = exportSchema{
+	label: "username",
+	node:  []exportAttr{{"top_patterns", "string"}, {"community", "int"}},
+}
+
+func (n ExportNode) graphNode() graphNode {
+	return graphNode{ID: n.ID.String(), Label: n.Username, Attrs: []interface{}{n.TopPatterns, n.Community}}
+}
+
+func (e ExportEdge) graphEdge() graphEdge {
+	return graphEdge{Source: e.Source.String(), Target: e.Target.String(), Weight: e.Similarity}
+}
+
+// WriteGraph streams the active graph version in the given format to w.
+func WriteGraph(db *gorm.DB, w io.Writer, format string, f ExportFilter) error {
+	gw, err := newGraphWriter(format, w, userSchema)
+	if err != nil {
+		return err
+	}
+	if f.Radius < 0 || f.Radius > MaxQueryHops {
+		return errors.New("radius out of range")
+	}
+
+	var (
+		version uint
+		members []uuid.UUID
+		ego     Subgraph
+	)
+	if f.Ego != nil {
+		adj, err := LoadAdjacency(db)
+		if err != nil {
+			return err
+		}
+		version = adj.Version
+		ego = adj.EgoNetwork(*f.Ego, f.Radius, f.MinSimilarity)
+		members = make([]uuid.UUID, len(ego.Nodes))
+		for i, n := range ego.Nodes {
+			members[i] = n.UserID
+		}
+	} else {
+		version, err = ActiveVersion(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := gw.begin(); err != nil {
+		return err
+	}
+	if err := streamNodes(db, version, f, members, func(n ExportNode) error {
+		return gw.node(n.graphNode())
+	}); err != nil {
+		return err
+	}
+	if err := gw.beginEdges(); err != nil {
+		return err
+	}
+	emitEdge := func(e ExportEdge) error {
+		return gw.edge(e.graphEdge())
+	}
+	if f.Ego != nil {
+		err = writeSubgraphEdges(ego, emitEdge)
+	} else {
+		err = streamEdges(db, version, f, emitEdge)
+	}
+	if err != nil {
+		return err
+	}
+	return gw.end()
+}
+
+// nodeScope returns the SQL condition selecting exported user IDs for
+// column col, with its arguments.
+func nodeScope(col string, version uint, f ExportFilter, members []uuid.UUID) (string, []interface{}) {
+	if f.Ego != nil {
+		return col + " IN ?", []interface{}{members}
+	}
+	return col + ` IN (
+			SELECT user_a FROM user_similarity_edges WHERE version = ? AND similarity >= ?
+			UNION
+			SELECT user_b FROM user_similarity_edges WHERE version = ? AND similarity >= ?
+		)`, []interface{}{version, f.MinSimilarity, version, f.MinSimilarity}
+}
+
+func streamNodes(db *gorm.DB, version uint, f ExportFilter, members []uuid.UUID, emit func(ExportNode) error) error {
+	scope, scopeArgs := nodeScope("u.id", version, f, members)
+	nodes, err := db.Raw(`
+		SELECT u.id, u.username, COALESCE(uc.community_id, 0)
+		FROM users u
+		LEFT JOIN user_communities uc ON uc.user_id = u.id AND uc.version = ?
+		WHERE `+scope+`
+		ORDER BY u.id
+	`, append([]interface{}{version}, scopeArgs...)...).Rows()
+	if err != nil {
+		return err
+	}
+	defer nodes.Close()
+
+	scope, scopeArgs = nodeScope("cs.user_id", version, f, members)
+	patterns, err := db.Raw(`
+		SELECT cs.user_id, ap.name, COUNT(*) AS uses
+		FROM code_submissions cs
+		JOIN submission_patterns sp ON sp.submission_id = cs.id
+		JOIN algorithm_patterns ap ON ap.id = sp.pattern_id
+		WHERE `+scope+`
+		GROUP BY cs.user_id, ap.name
+		ORDER BY cs.user_id, uses DESC, ap.name
+	`, scopeArgs...).Rows()
+	if err != nil {
+		return err
+	}
+	defer patterns.Close()
+
+	pc := &patternCursor{rows: patterns}
+	for nodes.Next() {
+		var n ExportNode
+		if err := nodes.Scan(&n.ID, &n.Username, &n.Community); err != nil {
+			return err
+		}
+		top, err := pc.take(n.ID)
+		if err != nil {
+			return err
+		}
+		n.TopPatterns = top
+		if err := emit(n); err != nil {
+			return err
+		}
+	}
+	return nodes.Err()
+}
+
+// patternCursor walks (user_id, pattern) rows ordered by user_id in step
+// with the node cursor.
+type patternCursor struct {
+	rows    *sql.Rows
+	user    uuid.UUID
+	name    string
+	pending bool
+	done    bool
+}
+
+// take returns up to exportTopPatterns patterns of userID, skipping rows of
+// users that sort before it.
+func (pc *patternCursor) take(userID uuid.UUID) ([]string, error) {
+	top := []string{}
+	for {
+		if !pc.pending {
+			if pc.done || !pc.rows.Next() {
+				pc.done = true
+				return top, pc.rows.Err()
+			}
+			var uses int
+			if err := pc.rows.Scan(&pc.user, &pc.name, &uses); err != nil {
+				return nil, err
+			}
+			pc.pending = true
+		}
+
+		// Postgres orders uuid values bytewise, which matches the order of
+		// their canonical lowercase string form.
+		switch cmp := strings.Compare(pc.user.String(), userID.String()); {
+		case cmp < 0:
+			pc.pending = false
+		case cmp == 0:
+			if len(top) < exportTopPatterns {
+				top = append(top, pc.name)
+			}
+			pc.pending = false
+		default:
+			return top, nil
+		}
+	}
+}
+
+func streamEdges(db *gorm.DB, version uint, f ExportFilter, emit func(ExportEdge) error) error {
+	rows, err := db.Model(&UserSimilarityEdge{}).
+		Select("user_a, user_b, similarity").
+		Where("version = ? AND similarity >= ?", version, f.MinSimilarity).
+		Order("user_a, user_b").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e ExportEdge
+		if err := rows.Scan(&e.Source, &e.Target, &e.Similarity); err != nil {
+			return err
+		}
+		if err := emit(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// writeSubgraphEdges emits the edges of an ego network in the same
+// (source, target) order as streamEdges.
+func writeSubgraphEdges(g Subgraph, emit func(ExportEdge) error) error {
+	edges := append([]SubgraphEdge(nil), g.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source.String() < edges[j].Source.String()
+		}
+		return edges[i].Target.String() < edges[j].Target.String()
+	})
+
+	for _, e := range edges {
+		if err := emit(ExportEdge{Source: e.Source, Target: e.Target, Similarity: e.Similarity}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatGraphML   = "graphml"
+	FormatGEXF      = "gexf"
+	FormatDOT       = "dot"
+	FormatCytoscape = "cytoscape"
+)
+
+// ExportFormats lists the supported export formats.
+func ExportFormats() []string {
+	return []string{FormatCytoscape, FormatDOT, FormatGEXF, FormatGraphML}
+}
+
+// ExportContentType returns the MIME type and file extension of a format.
+func ExportContentType(format string) (string, string) {
+	switch format {
+	case FormatGraphML:
+		return "application/graphml+xml", ".graphml"
+	case FormatGEXF:
+		return "application/gexf+xml", ".gexf"
+	case FormatDOT:
+		return "text/vnd.graphviz", ".dot"
+	case FormatCytoscape:
+		return "application/json", ".json"
+	}
+	return "application/octet-stream", ""
+}
+
+// exportAttr is one node or edge attribute of an export schema. Kind is
+// "string", "int" or "double".
+type exportAttr struct {
+	name string
+	kind string
+}
+
+// exportSchema describes the attributes written for a kind of graph. Label
+// names the node label; every node carries the node attributes in order and
+// every edge a weight followed by the edge attributes.
+type exportSchema struct {
+	label string
+	node  []exportAttr
+	edge  []exportAttr
+}
+
+// graphNode and graphEdge are the format-independent rows handed to a
+// graphWriter. Attrs hold one value per schema attribute: a string,
+// []string, int or float64.
+type graphNode struct {
+	ID    string
+	Label string
+	Attrs []interface{}
+}
+
+type graphEdge struct {
+	Source string
+	Target string
+	Weight float64
+	Attrs  []interface{}
+}
+
+// graphWriter receives all nodes, then all edges, in one pass.
+type graphWriter interface {
+	begin() error
+	node(n graphNode) error
+	beginEdges() error
+	edge(e graphEdge) error
+	end() error
+}
+
+func newGraphWriter(format string, w io.Writer, schema exportSchema) (graphWriter, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatGraphML:
+		return &graphMLWriter{w: bw, schema: schema}, nil
+	case FormatGEXF:
+		return &gexfWriter{w: bw, schema: schema}, nil
+	case FormatDOT:
+		return &dotWriter{w: bw, schema: schema}, nil
+	case FormatCytoscape:
+		return &cytoscapeWriter{w: bw, schema: schema}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func formatWeight(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatAttr renders an attribute value as text; lists are comma-joined.
+func formatAttr(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return formatWeight(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// ── GraphML ─────────────────────────────────────────────────────────────────
+
+type graphMLWriter struct {
+	w      *bufio.Writer
+	schema exportSchema
+	edges  int
+}
+
+// graphMLEdgeKey is the key ID of an edge attribute. GraphML key IDs are
+// shared by nodes and edges, so edge keys get a prefix.
+func graphMLEdgeKey(name string) string {
+	return "edge_" + name
+}
+
+func (g *graphMLWriter) begin() error {
+	g.w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+`)
+	fmt.Fprintf(g.w, "  <key id=\"%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", g.schema.label, g.schema.label)
+	for _, a := range g.schema.node {
+		fmt.Fprintf(g.w, "  <key id=\"%s\" for=\"node\" attr.name=\"%s\" attr.type=\"%s\"/>\n", a.name, a.name, a.kind)
+	}
+	g.w.WriteString("  <key id=\"weight\" for=\"edge\" attr.name=\"weight\" attr.type=\"double\"/>\n")
+	for _, a := range g.schema.edge {
+		fmt.Fprintf(g.w, "  <key id=\"%s\" for=\"edge\" attr.name=\"%s\" attr.type=\"%s\"/>\n", graphMLEdgeKey(a.name), a.name, a.kind)
+	}
+	_, err := g.w.WriteString("  <graph id=\"devgraph\" edgedefault=\"undirected\">\n")
+	return err
+}
+
+func (g *graphMLWriter) data(key string, v interface{}, indent string) {
+	fmt.Fprintf(g.w, "%s<data key=\"%s\">%s</data>\n", indent, key, xmlEscape(formatAttr(v)))
+}
+
+func (g *graphMLWriter) node(n graphNode) error {
+	fmt.Fprintf(g.w, "    <node id=\"%s\">\n", xmlEscape(n.ID))
+	g.data(g.schema.label, n.Label, "      ")
+	for i, a := range g.schema.node {
+		g.data(a.name, n.Attrs[i], "      ")
+	}
+	_, err := g.w.WriteString("    </node>\n")
+	return err
+}
+
+func (g *graphMLWriter) beginEdges() error { return nil }
+
+func (g *graphMLWriter) edge(e graphEdge) error {
+	g.edges++
+	fmt.Fprintf(g.w, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", g.edges, xmlEscape(e.Source), xmlEscape(e.Target))
+	g.data("weight", e.Weight, "      ")
+	for i, a := range g.schema.edge {
+		g.data(graphMLEdgeKey(a.name), e.Attrs[i], "      ")
+	}
+	_, err := g.w.WriteString("    </edge>\n")
+	return err
+}
+
+func (g *graphMLWriter) end() error {
+	if _, err := g.w.WriteString("  </graph>\n</graphml>\n"); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
+
+// ── GEXF ────────────────────────────────────────────────────────────────────
+
+type gexfWriter struct {
+	w      *bufio.Writer
+	schema exportSchema
+	edges  int
+}
+
+// gexfKind maps schema kinds to GEXF attribute types.
+func gexfKind(kind string) string {
+	if kind == "int" {
+		return "integer"
+	}
+	return kind
+}
+
+func (g *gexfWriter) attributes(class string, attrs []exportAttr) {
+	if len(attrs) == 0 {
+		return
+	}
+	fmt.Fprintf(g.w, "    <attributes class=\"%s\">\n", class)
+	for i, a := range attrs {
+		fmt.Fprintf(g.w, "      <attribute id=\"%d\" title=\"%s\" type=\"%s\"/>\n", i, a.name, gexfKind(a.kind))
+	}
+	g.w.WriteString("    </attributes>\n")
+}
+
+func (g *gexfWriter) attvalues(values []interface{}, indent string) {
+	if len(values) == 0 {
+		return
+	}
+	g.w.WriteString(indent + "<attvalues>\n")
+	for i, v := range values {
+		fmt.Fprintf(g.w, "%s  <attvalue for=\"%d\" value=\"%s\"/>\n", indent, i, xmlEscape(formatAttr(v)))
+	}
+	g.w.WriteString(indent + "</attvalues>\n")
+}
+
+func (g *gexfWriter) begin() error {
+	fmt.Fprintf(g.w, `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" version="1.3">
+  <meta lastmodifieddate="%s">
+    <creator>devgraph</creator>
+  </meta>
+  <graph defaultedgetype="undirected">
+`, time.Now().Format("2006-01-02"))
+	g.attributes("node", g.schema.node)
+	g.attributes("edge", g.schema.edge)
+	_, err := g.w.WriteString("    <nodes>\n")
+	return err
+}
+
+func (g *gexfWriter) node(n graphNode) error {
+	fmt.Fprintf(g.w, "      <node id=\"%s\" label=\"%s\">\n", xmlEscape(n.ID), xmlEscape(n.Label))
+	g.attvalues(n.Attrs, "        ")
+	_, err := g.w.WriteString("      </node>\n")
+	return err
+}
+
+func (g *gexfWriter) beginEdges() error {
+	_, err := g.w.WriteString("    </nodes>\n    <edges>\n")
+	return err
+}
+
+func (g *gexfWriter) edge(e graphEdge) error {
+	g.edges++
+	fmt.Fprintf(g.w, "      <edge id=\"%d\" source=\"%s\" target=\"%s\" weight=\"%s\"",
+		g.edges, xmlEscape(e.Source), xmlEscape(e.Target), formatWeight(e.Weight))
+	if len(e.Attrs) == 0 {
+		_, err := g.w.WriteString("/>\n")
+		return err
+	}
+	g.w.WriteString(">\n")
+	g.attvalues(e.Attrs, "        ")
+	_, err := g.w.WriteString("      </edge>\n")
+	return err
+}
+
+func (g *gexfWriter) end() error {
+	if _, err := g.w.WriteString("    </edges>\n  </graph>\n</gexf>\n"); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
+
+// ── Graphviz DOT ────────────────────────────────────────────────────────────
+
+type dotWriter struct {
+	w      *bufio.Writer
+	schema exportSchema
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// dotValue quotes text attributes and leaves numbers bare.
+func dotValue(v interface{}) string {
+	switch v.(type) {
+	case int, float64:
+		return formatAttr(v)
+	}
+	return dotQuote(formatAttr(v))
+}
+
+func (d *dotWriter) begin() error {
+	_, err := d.w.WriteString("graph devgraph {\n")
+	return err
+}
+
+func (d *dotWriter) node(n graphNode) error {
+	fmt.Fprintf(d.w, "  %s [label=%s", dotQuote(n.ID), dotQuote(n.Label))
+	for i, a := range d.schema.node {
+		fmt.Fprintf(d.w, ", %s=%s", a.name, dotValue(n.Attrs[i]))
+	}
+	_, err := d.w.WriteString("];\n")
+	return err
+}
+
+func (d *dotWriter) beginEdges() error { return nil }
+
+func (d *dotWriter) edge(e graphEdge) error {
+	fmt.Fprintf(d.w, "  %s -- %s [weight=%s", dotQuote(e.Source), dotQuote(e.Target), formatWeight(e.Weight))
+	for i, a := range d.schema.edge {
+		fmt.Fprintf(d.w, ", %s=%s", a.name, dotValue(e.Attrs[i]))
+	}
+	_, err := d.w.WriteString("];\n")
+	return err
+}
+
+func (d *dotWriter) end() error {
+	if _, err := d.w.WriteString("}\n"); err != nil {
+		return err
+	}
+	return d.w.Flush()
+}
+
+// ── Cytoscape.js JSON ───────────────────────────────────────────────────────
+
+// cytoscapeWriter emits {"elements":{"nodes":[…],"edges":[…]}}, encoding
+// one element at a time.
+type cytoscapeWriter struct {
+	w      *bufio.Writer
+	schema exportSchema
+	first  bool
+	edges  int
+}
+
+type cytoscapeElement struct {
+	Data interface{} `json:"data"`
+}
+
+func (c *cytoscapeWriter) element(v interface{}) error {
+	if !c.first {
+		if err := c.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	c.first = false
+	b, err := json.Marshal(cytoscapeElement{Data: v})
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(b)
+	return err
+}
+
+func (c *cytoscapeWriter) begin() error {
+	c.first = true
+	_, err := c.w.WriteString(`{"elements":{"nodes":[`)
+	return err
+}
+
+func (c *cytoscapeWriter) node(n graphNode) error {
+	data := map[string]interface{}{
+		"id":           n.ID,
+		c.schema.label: n.Label,
+	}
+	for i, a := range c.schema.node {
+		data[a.name] = n.Attrs[i]
+	}
+	return c.element(data)
+}
+
+func (c *cytoscapeWriter) beginEdges() error {
+	c.first = true
+	_, err := c.w.WriteString(`],"edges":[`)
+	return err
+}
+
+func (c *cytoscapeWriter) edge(e graphEdge) error {
+	c.edges++
+	data := map[string]interface{}{
+		"id":     "e" + strconv.Itoa(c.edges),
+		"source": e.Source,
+		"target": e.Target,
+		"weight": e.Weight,
+	}
+	for i, a := range c.schema.edge {
+		data[a.name] = e.Attrs[i]
+	}
+	return c.element(data)
+}
+
+func (c *cytoscapeWriter) end() error {
+	if _, err := c.w.WriteString("]}}\n"); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
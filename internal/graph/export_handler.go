@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportGraph handles GET /api/graph/export. The export includes usernames
+// across the whole graph and is mounted for admins and the scheduler only.
+//
+// Query parameters: format (graphml, gexf, dot or cytoscape; default
+// graphml), min_similarity, and ego plus radius (default 1) to export only
+// one user's neighbourhood. The response is streamed as it is read from the
+// database.
+func ExportGraph(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", FormatGraphML)
+		contentType, ext := ExportContentType(format)
+		if ext == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown format", "formats": ExportFormats()})
+			return
+		}
+
+		minSimilarity, ok := parseMinSimilarity(c)
+		if !ok {
+			return
+		}
+		filter := ExportFilter{MinSimilarity: minSimilarity}
+
+		if v := c.Query("ego"); v != "" {
+			ego, err := uuid.Parse(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ego user id"})
+				return
+			}
+			radius, err := strconv.Atoi(c.DefaultQuery("radius", "1"))
+			if err != nil || radius < 0 || radius > MaxQueryHops {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be between 0 and " + strconv.Itoa(MaxQueryHops)})
+				return
+			}
+			filter.Ego = &ego
+			filter.Radius = radius
+		}
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="devgraph`+ext+`"`)
+		c.Status(http.StatusOK)
+
+		// Headers are already sent, so a failure can only truncate the body.
+		if err := WriteGraph(db, c.Writer, format, filter); err != nil {
+			log.Println("graph export failed:", err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package graph
+
+// feedback.go — accept/dismiss feedback on recommendations
+//
+// Users who already gave feedback on someone no longer see them
+// recommended. The feedback history also re-ranks the remaining
+// candidates: for each pattern p let dismissed(p) and accepted(p) be the
+// share of the caller's dismissed and accepted users who use p. For a
+// candidate sharing the patterns S with the caller,
+//
+//	penalty = mean dismissed(p) over S
+//	boost   = mean accepted(p)  over S
+//	score   = similarity · (1 − feedbackPenalty·penalty) · (1 + feedbackBoost·boost)
+//
+// so candidates resembling people the caller turned down sink and those
+// resembling accepted ones rise. Each feedback row records the metric and
+// similarity of the edge it was given on, which makes acceptance rates
+// comparable across similarity metrics.
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	FeedbackAccept  = "accept"
+	FeedbackDismiss = "dismiss"
+
+	feedbackPenalty = 0.5
+	feedbackBoost   = 0.25
+)
+
+// RecommendationFeedback is one user's latest verdict on a recommended
+// user; giving feedback again replaces it.
+type RecommendationFeedback struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_feedback_pair"`
+	TargetUserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_feedback_pair"`
+	Action       string    `gorm:"not null;index"`
+	Metric       string    `gorm:"not null;index"`
+	Similarity   float64   `gorm:"not null"`
+	Version      uint      `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// feedbackHistory holds the caller's accepted and dismissed users.
+type feedbackHistory struct {
+	accepted  []uuid.UUID
+	dismissed []uuid.UUID
+}
+
+func (h feedbackHistory) empty() bool {
+	return len(h.accepted) == 0 && len(h.dismissed) == 0
+}
+
+func (h feedbackHistory) all() []uuid.UUID {
+	return append(append([]uuid.UUID{}, h.accepted...), h.dismissed...)
+}
+
+func loadFeedback(db *gorm.DB, userID uuid.UUID) (feedbackHistory, error) {
+	var rows []RecommendationFeedback
+	if err := db.Select("target_user_id, action").Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return feedbackHistory{}, err
+	}
+
+	var h feedbackHistory
+	for _, r := range rows {
+		if r.Action == FeedbackAccept {
+			h.accepted = append(h.accepted, r.TargetUserID)
+		} else {
+			h.dismissed = append(h.dismissed, r.TargetUserID)
+		}
+	}
+	return h, nil
+}
+
+// patternShares returns, for every pattern, the share of users using it.
+func patternShares(users []uuid.UUID, patterns map[uuid.UUID][]string) map[string]float64 {
+	shares := map[string]float64{}
+	if len(users) == 0 {
+		return shares
+	}
+	for _, u := range users {
+		for _, p := range patterns[u] {
+			shares[p] += 1 / float64(len(users))
+		}
+	}
+	return shares
+}
+
+// feedbackScore applies the re-ranking formula to one candidate.
+func feedbackScore(similarity float64, shared []string, accepted, dismissed map[string]float64) float64 {
+	if len(shared) == 0 {
+		return similarity
+	}
+	penalty, boost := 0.0, 0.0
+	for _, p := range shared {
+		penalty += dismissed[p]
+		boost += accepted[p]
+	}
+	penalty /= float64(len(shared))
+	boost /= float64(len(shared))
+	return similarity * (1 - feedbackPenalty*penalty) * (1 + feedbackBoost*boost)
+}
+
+// AcceptRecommendation handles POST /api/recommendations/:user_id/accept.
+func AcceptRecommendation(db *gorm.DB) gin.HandlerFunc {
+	return recordFeedback(db, FeedbackAccept)
+}
+
+// DismissRecommendation handles POST /api/recommendations/:user_id/dismiss.
+func DismissRecommendation(db *gorm.DB) gin.HandlerFunc {
+	return recordFeedback(db, FeedbackDismiss)
+}
+
+func recordFeedback(db *gorm.DB, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		target, err := uuid.Parse(c.Param("user_id"))
+		if err != nil || target == userID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		var edge UserSimilarityEdge
+		err = db.Where("version = ? AND ((user_a = ? AND user_b = ?) OR (user_a = ? AND user_b = ?))",
+			version, userID, target, target, userID).Take(&edge).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "recommendation not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load recommendation"})
+			return
+		}
+
+		feedback := RecommendationFeedback{
+			ID:           uuid.New(),
+			UserID:       userID,
+			TargetUserID: target,
+			Action:       action,
+			Metric:       edge.Metric,
+			Similarity:   edge.Similarity,
+			Version:      version,
+			CreatedAt:    time.Now(),
+		}
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "target_user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"action", "metric", "similarity", "version", "created_at"}),
+		}).Create(&feedback).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record feedback"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "feedback recorded", "action": action})
+	}
+}
+
+type MetricFeedbackStats struct {
+	Metric         string  `json:"metric"`
+	Accepted       int     `json:"accepted"`
+	Dismissed      int     `json:"dismissed"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+// GetFeedbackStats handles GET /api/recommendations/feedback/stats and
+// reports the acceptance rate of recommendations per similarity metric.
+func GetFeedbackStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats := []MetricFeedbackStats{}
+		if err := db.Model(&RecommendationFeedback{}).
+			Select(`metric,
+				SUM(CASE WHEN action = ? THEN 1 ELSE 0 END) AS accepted,
+				SUM(CASE WHEN action = ? THEN 1 ELSE 0 END) AS dismissed`,
+				FeedbackAccept, FeedbackDismiss).
+			Group("metric").
+			Order("metric").
+			Scan(&stats).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feedback stats"})
+			return
+		}
+
+		for i, s := range stats {
+			if total := s.Accepted + s.Dismissed; total > 0 {
+				stats[i].AcceptanceRate = float64(s.Accepted) / float64(total)
+			}
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,48 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFeedbackScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		shared    []string
+		accepted  map[string]float64
+		dismissed map[string]float64
+		want      float64
+	}{
+		{"no shared patterns", nil, map[string]float64{"Loop": 1}, map[string]float64{"Loop": 1}, 0.8},
+		{"no history", []string{"Loop"}, nil, nil, 0.8},
+		{"fully dismissed", []string{"Loop"}, nil, map[string]float64{"Loop": 1}, 0.8 * (1 - feedbackPenalty)},
+		{"fully accepted", []string{"Loop"}, map[string]float64{"Loop": 1}, nil, 0.8 * (1 + feedbackBoost)},
+		{"averaged over shared", []string{"Loop", "Hashing"}, nil, map[string]float64{"Loop": 1}, 0.8 * (1 - feedbackPenalty/2)},
+		{"unshared history ignored", []string{"Hashing"}, map[string]float64{"Loop": 1}, map[string]float64{"Sorting": 1}, 0.8},
+		{
+			"both", []string{"Loop", "Hashing"},
+			map[string]float64{"Hashing": 0.5}, map[string]float64{"Loop": 0.5},
+			0.8 * (1 - feedbackPenalty*0.25) * (1 + feedbackBoost*0.25),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feedbackScore(0.8, tt.shared, tt.accepted, tt.dismissed); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("feedbackScore = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedbackScoreNeverReorders(t *testing.T) {
+	// With the same feedback, a more similar user always scores higher.
+	shared := []string{"Loop", "Recursion"}
+	dismissed := map[string]float64{"Loop": 1, "Recursion": 1}
+	if lo, hi := feedbackScore(0.3, shared, nil, dismissed), feedbackScore(0.6, shared, nil, dismissed); lo >= hi {
+		t.Errorf("score of 0.3 (%v) not below score of 0.6 (%v)", lo, hi)
+	}
+	if got := feedbackScore(0.5, shared, nil, dismissed); got <= 0 {
+		t.Errorf("fully dismissed score = %v, want positive", got)
+	}
+}
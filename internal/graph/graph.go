@@ -6,20 +6,55 @@ type UserSimilarity struct {
 	Similarity float64
 }
 
+// BuildSimilarityGraph returns the pairs of profiles whose weighted Jaccard
+// similarity is at or above the threshold; see BuildSimilarityGraphWith.
 func BuildSimilarityGraph(profiles []UserPatternProfile, threshold float64) []UserSimilarity {
+	return BuildSimilarityGraphWith(profiles, &weightedJaccardMetric{}, threshold)
+}
+
+// BuildSimilarityGraphWith fits metric on the population and returns the
+// pairs scoring at or above the threshold. Small populations are compared
+// exhaustively. Large ones go through the index in lsh.go, which only scores
+// pairs similar on their pattern and problem features, so edges carried by
+// shared languages or complexity classes alone are not found.
+func BuildSimilarityGraphWith(profiles []UserPatternProfile, metric SimilarityMetric, threshold float64) []UserSimilarity {
+	return buildSimilarityGraph(profiles, metric, NewCorpusStats(profiles), threshold, nil)
+}
+
+// buildSimilarityGraph is BuildSimilarityGraphWith with precomputed corpus
+// statistics, reporting the number of scored pairs to progress.
+func buildSimilarityGraph(profiles []UserPatternProfile, metric SimilarityMetric, stats CorpusStats, threshold float64, progress *BuildProgress) []UserSimilarity {
+	metric.Fit(stats)
+	vectors := profileVectors(profiles, metric)
+
+	if len(profiles) >= lshMinProfiles {
+		return lshSimilarityGraph(profiles, vectors, metric, stats, threshold, progress)
+	}
+	return bruteForceSimilarityGraph(profiles, vectors, metric, threshold, progress)
+}
+
+func profileVectors(profiles []UserPatternProfile, metric SimilarityMetric) []map[string]float64 {
+	vectors := make([]map[string]float64, len(profiles))
+	for i, p := range profiles {
+		vectors[i] = metric.Vector(p)
+	}
+	return vectors
+}
+
+// bruteForceSimilarityGraph scores all n(n-1)/2 pairs.
+func bruteForceSimilarityGraph(profiles []UserPatternProfile, vectors []map[string]float64, metric SimilarityMetric, threshold float64, progress *BuildProgress) []UserSimilarity {
 	edges := []UserSimilarity{}
 
-	for i := 0; i < len(profiles); i++ {
+	for i := 0; i < len(profiles) && !progress.aborted(); i++ {
+		progress.addPairs(len(profiles) - i - 1)
 		for j := i + 1; j < len(profiles); j++ {
-			score := WeightedJaccard(
-				profiles[i].Patterns,
-				profiles[j].Patterns,
-			)
+			score := metric.Compare(vectors[i], vectors[j])
 
 			if score >= threshold {
+				a, b := orderPair(profiles[i].UserID, profiles[j].UserID)
 				edges = append(edges, UserSimilarity{
-					UserA:      profiles[i].UserID,
-					UserB:      profiles[j].UserID,
+					UserA:      a,
+					UserB:      b,
 					Similarity: score,
 				})
 			}
@@ -1,84 +1,305 @@
 package graph
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"devgraph/internal/connection"
+	"devgraph/internal/user"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	defaultRecommendationLimit = 5
+	maxRecommendationLimit     = 50
+)
+
+type RecommendedUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatar_url"`
+}
+
 type RecommendationDetail struct {
-	ID             uuid.UUID `json:"id"`
-	UserA          uuid.UUID `json:"user_a"`
-	UserB          uuid.UUID `json:"user_b"`
-	Similarity     float64   `json:"similarity"`
-	CreatedAt      string    `json:"created_at"`
-	SharedPatterns []string  `json:"shared_patterns"`
-	TotalPatterns  int       `json:"total_patterns"`
-	UserAPatterns  []string  `json:"user_a_patterns"`
-	UserBPatterns  []string  `json:"user_b_patterns"`
+	ID             uuid.UUID       `json:"id"`
+	User           RecommendedUser `json:"user"`
+	Similarity     float64         `json:"similarity"`
+	Score          float64         `json:"score"`
+	Metric         string          `json:"metric"`
+	CreatedAt      string          `json:"created_at"`
+	SharedPatterns []string        `json:"shared_patterns"`
+	TotalPatterns  int             `json:"total_patterns"`
+	YourPatterns   []string        `json:"your_patterns"`
+	TheirPatterns  []string        `json:"their_patterns"`
 }
 
+type RecommendationPage struct {
+	Recommendations []RecommendationDetail `json:"recommendations"`
+	NextCursor      string                 `json:"next_cursor,omitempty"`
+}
+
+// GetRecommendations handles GET /api/recommendations.
+//
+// Query parameters: limit (default 5, at most 50), min_similarity and
+// cursor, the opaque next_cursor of the previous page. Results describe the
+// other user of each edge and are ordered by score, strongest first. Users
+// the caller already accepted or dismissed are left out, as are existing
+// connections, pending connection requests and blocks in either direction.
+// Once the caller has given feedback, score is the similarity re-ranked by
+// feedback.go; otherwise it equals the similarity.
 func GetRecommendations(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.MustGet("user_id").(uuid.UUID)
 
-		var edges []UserSimilarityEdge
-		db.Where("user_a = ? OR user_b = ?", userID, userID).
-			Order("similarity DESC").
-			Limit(5).
-			Find(&edges)
-
-		// Enrich with pattern details
-		recommendations := []RecommendationDetail{}
-		for _, edge := range edges {
-			// Get patterns for both users
-			userAPatterns := getUserPatterns(db, edge.UserA)
-			userBPatterns := getUserPatterns(db, edge.UserB)
-
-			// Find shared patterns
-			sharedPatterns := findSharedPatterns(userAPatterns, userBPatterns)
-
-			recommendations = append(recommendations, RecommendationDetail{
-				ID:             edge.ID,
-				UserA:          edge.UserA,
-				UserB:          edge.UserB,
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRecommendationLimit)))
+		if err != nil || limit < 1 || limit > maxRecommendationLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxRecommendationLimit)})
+			return
+		}
+
+		minSimilarity, ok := parseMinSimilarity(c)
+		if !ok {
+			return
+		}
+
+		var after *recommendationCursor
+		if raw := c.Query("cursor"); raw != "" {
+			score, id, err := decodeCursor(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+			after = &recommendationCursor{score: score, id: id}
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		history, err := loadFeedback(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load feedback"})
+			return
+		}
+
+		excluded, err := connection.ExcludedUsers(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load connections"})
+			return
+		}
+
+		query := db.Where("version = ? AND (user_a = ? OR user_b = ?) AND similarity >= ?",
+			version, userID, userID, minSimilarity)
+		if skip := append(history.all(), excluded...); len(skip) > 0 {
+			query = query.Where("user_a NOT IN ? AND user_b NOT IN ?", skip, skip)
+		}
+
+		var candidates []scoredEdge
+		var patterns map[uuid.UUID][]string
+		if history.empty() {
+			candidates, err = similarityPage(query, after, limit)
+		} else {
+			candidates, patterns, err = rerankedPage(db, query, userID, history, after, limit)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load recommendations"})
+			return
+		}
+
+		page := RecommendationPage{Recommendations: []RecommendationDetail{}}
+		if len(candidates) > limit {
+			candidates = candidates[:limit]
+			last := candidates[limit-1]
+			page.NextCursor = encodeCursor(last.score, last.edge.ID)
+		}
+		if len(candidates) == 0 {
+			c.JSON(http.StatusOK, page)
+			return
+		}
+
+		ids := []uuid.UUID{userID}
+		for _, cand := range candidates {
+			ids = append(ids, otherUser(cand.edge, userID))
+		}
+
+		if patterns == nil {
+			patterns, err = patternsByUser(db, ids)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load patterns"})
+				return
+			}
+		}
+
+		var users []user.User
+		if err := db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load users"})
+			return
+		}
+		byID := make(map[uuid.UUID]user.User, len(users))
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+
+		yours := patterns[userID]
+		for _, cand := range candidates {
+			edge := cand.edge
+			other := otherUser(edge, userID)
+			theirs := patterns[other]
+			shared := findSharedPatterns(yours, theirs)
+
+			page.Recommendations = append(page.Recommendations, RecommendationDetail{
+				ID: edge.ID,
+				User: RecommendedUser{
+					ID:        other,
+					Username:  byID[other].Username,
+					AvatarURL: byID[other].AvatarURL,
+				},
 				Similarity:     edge.Similarity,
+				Score:          cand.score,
+				Metric:         edge.Metric,
 				CreatedAt:      edge.CreatedAt.Format("2006-01-02"),
-				SharedPatterns: sharedPatterns,
-				TotalPatterns:  len(sharedPatterns),
-				UserAPatterns:  userAPatterns,
-				UserBPatterns:  userBPatterns,
+				SharedPatterns: shared,
+				TotalPatterns:  len(shared),
+				YourPatterns:   yours,
+				TheirPatterns:  theirs,
 			})
 		}
 
-		c.JSON(http.StatusOK, recommendations)
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func getUserPatterns(db *gorm.DB, userID uuid.UUID) []string {
+type scoredEdge struct {
+	edge  UserSimilarityEdge
+	score float64
+}
+
+type recommendationCursor struct {
+	score float64
+	id    uuid.UUID
+}
+
+// before reports whether (score, id) sorts after the cursor position.
+func (rc *recommendationCursor) before(score float64, id uuid.UUID) bool {
+	return rc == nil || score < rc.score || (score == rc.score && id.String() > rc.id.String())
+}
+
+// similarityPage returns up to limit+1 edges after the cursor, ordered by
+// similarity in SQL. Fetching one extra row tells whether another page
+// exists.
+func similarityPage(query *gorm.DB, after *recommendationCursor, limit int) ([]scoredEdge, error) {
+	if after != nil {
+		query = query.Where("(similarity < ? OR (similarity = ? AND id > ?))", after.score, after.score, after.id)
+	}
+
+	var edges []UserSimilarityEdge
+	if err := query.Order("similarity DESC, id").Limit(limit + 1).Find(&edges).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]scoredEdge, len(edges))
+	for i, e := range edges {
+		out[i] = scoredEdge{edge: e, score: e.Similarity}
+	}
+	return out, nil
+}
+
+// rerankedPage scores every candidate edge of the caller with the feedback
+// history and returns up to limit+1 of them after the cursor, together with
+// the patterns it loaded.
+func rerankedPage(db *gorm.DB, query *gorm.DB, userID uuid.UUID, history feedbackHistory, after *recommendationCursor, limit int) ([]scoredEdge, map[uuid.UUID][]string, error) {
+	var edges []UserSimilarityEdge
+	if err := query.Find(&edges).Error; err != nil {
+		return nil, nil, err
+	}
+
+	ids := append([]uuid.UUID{userID}, history.all()...)
+	for _, e := range edges {
+		ids = append(ids, otherUser(e, userID))
+	}
+	patterns, err := patternsByUser(db, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accepted := patternShares(history.accepted, patterns)
+	dismissed := patternShares(history.dismissed, patterns)
+
+	yours := patterns[userID]
+	scored := make([]scoredEdge, 0, len(edges))
+	for _, e := range edges {
+		shared := findSharedPatterns(yours, patterns[otherUser(e, userID)])
+		score := feedbackScore(e.Similarity, shared, accepted, dismissed)
+		if after.before(score, e.ID) {
+			scored = append(scored, scoredEdge{edge: e, score: score})
+		}
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].edge.ID.String() < scored[j].edge.ID.String()
+	})
+	if len(scored) > limit+1 {
+		scored = scored[:limit+1]
+	}
+	return scored, patterns, nil
+}
+
+// otherUser returns the endpoint of edge that is not userID.
+func otherUser(edge UserSimilarityEdge, userID uuid.UUID) uuid.UUID {
+	if edge.UserA == userID {
+		return edge.UserB
+	}
+	return edge.UserA
+}
+
+// patternsByUser loads the distinct pattern names of every given user in a
+// single query. Every requested user is present in the result, with an
+// empty list when they have no patterns.
+func patternsByUser(db *gorm.DB, userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
 	rows, err := db.Raw(`
-		SELECT DISTINCT ap.name
+		SELECT DISTINCT cs.user_id, ap.name
 		FROM code_submissions cs
 		JOIN submission_patterns sp ON cs.id = sp.submission_id
 		JOIN algorithm_patterns ap ON sp.pattern_id = ap.id
-		WHERE cs.user_id = ?
-	`, userID).Rows()
-
+		WHERE cs.user_id IN ?
+	`, userIDs).Rows()
 	if err != nil {
-		return []string{}
+		return nil, err
 	}
 	defer rows.Close()
 
-	patterns := []string{}
+	patterns := make(map[uuid.UUID][]string, len(userIDs))
+	for _, id := range userIDs {
+		patterns[id] = []string{}
+	}
 	for rows.Next() {
+		var userID uuid.UUID
 		var pattern string
-		rows.Scan(&pattern)
-		patterns = append(patterns, pattern)
+		if err := rows.Scan(&userID, &pattern); err != nil {
+			return nil, err
+		}
+		patterns[userID] = append(patterns[userID], pattern)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return patterns
+	for _, list := range patterns {
+		sort.Strings(list)
+	}
+	return patterns, nil
 }
 
 func findSharedPatterns(a, b []string) []string {
@@ -96,3 +317,31 @@ func findSharedPatterns(a, b []string) []string {
 
 	return shared
 }
+
+// encodeCursor packs the sort key (score and edge ID) of the last returned
+// recommendation. The score is formatted so that it parses back to the
+// identical float.
+func encodeCursor(score float64, id uuid.UUID) string {
+	raw := strconv.FormatFloat(score, 'g', -1, 64) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (float64, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return 0, uuid.Nil, errors.New("malformed cursor")
+	}
+	similarity, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	return similarity, id, nil
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"encoding/base64"
+	"math"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	scores := []float64{0, 1, 0.1, 1.0 / 3, 0.1 + 0.2, math.SmallestNonzeroFloat64, 0.999999999999999}
+
+	for _, score := range scores {
+		id := testUser(7)
+		gotScore, gotID, err := decodeCursor(encodeCursor(score, id))
+		if err != nil {
+			t.Fatalf("decode(encode(%v)): %v", score, err)
+		}
+		if gotScore != score || gotID != id {
+			t.Errorf("round trip of %v, %v gave %v, %v", score, id, gotScore, gotID)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsMalformed(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "***"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("0.5|" + testUser(1).String()))},
+		{"no separator", encode("0.5")},
+		{"bad score", encode("high|" + testUser(1).String())},
+		{"bad id", encode("0.5|not-a-uuid")},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCursor(tt.cursor); err == nil {
+				t.Errorf("decodeCursor(%q) succeeded", tt.cursor)
+			}
+		})
+	}
+}
+
+func TestRecommendationCursorBefore(t *testing.T) {
+	cursor := &recommendationCursor{score: 0.5, id: testUser(5)}
+
+	tests := []struct {
+		name   string
+		cursor *recommendationCursor
+		score  float64
+		id     int
+		want   bool
+	}{
+		{"first page", nil, 0.9, 1, true},
+		{"lower score", cursor, 0.4, 1, true},
+		{"higher score", cursor, 0.6, 9, false},
+		{"tie, later id", cursor, 0.5, 6, true},
+		{"tie, earlier id", cursor, 0.5, 4, false},
+		{"the cursor row itself", cursor, 0.5, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cursor.before(tt.score, testUser(tt.id)); got != tt.want {
+				t.Errorf("before(%v, %d) = %v, want %v", tt.score, tt.id, got, tt.want)
+			}
+		})
+	}
+}
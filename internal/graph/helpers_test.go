@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// testUser returns a fixed user ID; IDs sort in the order of n.
+func testUser(n int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", n))
+}
+
+// testEdges builds edges from (a, b, similarity) triples of testUser numbers.
+func testEdges(triples ...[3]float64) []UserSimilarityEdge {
+	edges := make([]UserSimilarityEdge, len(triples))
+	for i, t := range triples {
+		edges[i] = UserSimilarityEdge{
+			UserA:      testUser(int(t[0])),
+			UserB:      testUser(int(t[1])),
+			Similarity: t[2],
+		}
+	}
+	return edges
+}
+
+// testAdjacency builds an Adjacency snapshot from edges.
+func testAdjacency(edges []UserSimilarityEdge) *Adjacency {
+	adj := &Adjacency{neighbours: map[uuid.UUID][]Neighbour{}}
+	for _, e := range edges {
+		adj.addEdge(e.UserA, e.UserB, e.Similarity)
+	}
+	adj.sortNeighbours()
+	return adj
+}
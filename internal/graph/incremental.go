@@ -0,0 +1,307 @@
+package graph
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultThreshold is the minimum similarity for an edge to be stored.
+const DefaultThreshold = 0.1
+
+// maxUpdateCandidates bounds how many users an incremental update compares
+// against.
+const maxUpdateCandidates = 1000
+
+// UpdateUserGraph recomputes one user's similarity edges after new analysis
+// results, using the metric, threshold and feature weights of the active
+// graph version. Only the maxUpdateCandidates users sharing the most
+// patterns and problems are loaded and compared. Corpus-weighted metrics
+// are fitted with the active version's stored statistics rather than a
+// refit over the population; the periodic rebuild brings them up to date.
+// Edges to compared users are updated in place, inserted or, when they
+// fell below the threshold, removed; edges to users that were not compared
+// and all other users' edges are left untouched.
+func UpdateUserGraph(db *gorm.DB, userID uuid.UUID) error {
+	opts, err := ActiveBuildOptions(db)
+	if err != nil {
+		return err
+	}
+	metric, err := opts.metric()
+	if err != nil {
+		return err
+	}
+
+	self, err := BuildProfilesFor(db, []string{userID.String()}, opts.Weights)
+	if err != nil {
+		return err
+	}
+
+	edges := []UserSimilarity{}
+	var compared map[uuid.UUID]bool
+	if len(self) == 1 {
+		candidates, err := candidateNeighbours(db, userID, opts.Weights, maxUpdateCandidates)
+		if err != nil {
+			return err
+		}
+		compared = make(map[uuid.UUID]bool, len(candidates))
+		for _, id := range candidates {
+			if u, err := uuid.Parse(id); err == nil {
+				compared[u] = true
+			}
+		}
+
+		others, err := BuildProfilesFor(db, candidates, opts.Weights)
+		if err != nil {
+			return err
+		}
+
+		stats, err := ActiveCorpusStats(db, opts.Weights)
+		if err != nil {
+			return err
+		}
+		metric.Fit(stats)
+		selfVec := metric.Vector(self[0])
+
+		for _, other := range others {
+			score := metric.Compare(selfVec, metric.Vector(other))
+			if score >= opts.Threshold {
+				a, b := orderPair(self[0].UserID, other.UserID)
+				edges = append(edges, UserSimilarity{UserA: a, UserB: b, Similarity: score})
+			}
+		}
+	}
+
+	version, err := syncUserEdges(db, userID, compared, edges, opts.Metric)
+	if err != nil {
+		return err
+	}
+	// Caches of versioned graphs follow the version's generation (see
+	// LoadAdjacency); legacy edges have no counter and are reloaded.
+	if version == 0 {
+		InvalidateAdjacency()
+	}
+	return nil
+}
+
+// candidateNeighbours returns up to limit other users, ranked by how many
+// distinct patterns and solved problems they share with userID (for the
+// groups with a positive weight). Languages and complexity classes are not
+// counted: nearly everyone shares one, so they would make the whole
+// population a candidate, and the full build ignores them when choosing
+// candidates as well (see lsh.go).
+func candidateNeighbours(db *gorm.DB, userID uuid.UUID, weights FeatureWeights, limit int) ([]string, error) {
+	queries := []string{}
+	args := []interface{}{}
+
+	if weights.Patterns > 0 {
+		queries = append(queries, `
+			SELECT DISTINCT cs.user_id, sp.pattern_id AS feature
+			FROM code_submissions cs
+			JOIN submission_patterns sp ON sp.submission_id = cs.id
+			WHERE cs.user_id <> ? AND sp.pattern_id IN (
+				SELECT sp.pattern_id
+				FROM submission_patterns sp
+				JOIN code_submissions cs ON cs.id = sp.submission_id
+				WHERE cs.user_id = ?
+			)`)
+		args = append(args, userID, userID)
+	}
+	if weights.Problems > 0 {
+		queries = append(queries, `
+			SELECT DISTINCT user_id, problem_id AS feature
+			FROM code_submissions
+			WHERE user_id <> ? AND problem_id IN (
+				SELECT problem_id FROM code_submissions
+				WHERE user_id = ? AND problem_id IS NOT NULL
+			)`)
+		args = append(args, userID, userID)
+	}
+
+	ids := []string{}
+	if len(queries) == 0 {
+		return ids, nil
+	}
+	err := db.Raw(`
+		SELECT user_id FROM (`+strings.Join(queries, " UNION ALL ")+`) shared
+		GROUP BY user_id
+		ORDER BY COUNT(*) DESC, user_id
+		LIMIT ?
+	`, append(args, limit)...).Scan(&ids).Error
+	return ids, err
+}
+
+// syncUserEdges makes the active version's edges between userID and the
+// compared users equal to edges; a nil compared set covers every user.
+// Legacy rows stored in either (A,B) or (B,A) order are both matched, and
+// any duplicate pair beyond the first is deleted. The version's EdgeCount
+// follows the change, its Generation is bumped and the update is recorded
+// as a GraphUserUpdate. It returns the version that was updated.
+func syncUserEdges(db *gorm.DB, userID uuid.UUID, compared map[uuid.UUID]bool, edges []UserSimilarity, metric string) (uint, error) {
+	var version uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = ActiveVersion(tx)
+		if err != nil {
+			return err
+		}
+
+		var existing []UserSimilarityEdge
+		if err := tx.Where("version = ? AND (user_a = ? OR user_b = ?)", version, userID, userID).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+
+		byOther := map[uuid.UUID]UserSimilarityEdge{}
+		seen := map[uuid.UUID]bool{}
+		stale := []uuid.UUID{}
+		for _, e := range existing {
+			other := e.UserA
+			if other == userID {
+				other = e.UserB
+			}
+			if seen[other] {
+				stale = append(stale, e.ID)
+				continue
+			}
+			seen[other] = true
+			if compared != nil && !compared[other] {
+				continue
+			}
+			byOther[other] = e
+		}
+
+		now := time.Now()
+		inserted := 0
+		for _, e := range edges {
+			a, err := uuid.Parse(e.UserA)
+			if err != nil {
+				return err
+			}
+			b, err := uuid.Parse(e.UserB)
+			if err != nil {
+				return err
+			}
+			other := a
+			if other == userID {
+				other = b
+			}
+
+			if old, ok := byOther[other]; ok {
+				delete(byOther, other)
+				if err := tx.Model(&UserSimilarityEdge{}).Where("id = ?", old.ID).
+					Updates(map[string]interface{}{
+						"user_a":     a,
+						"user_b":     b,
+						"similarity": e.Similarity,
+						"metric":     metric,
+						"created_at": now,
+					}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			inserted++
+			if err := tx.Create(&UserSimilarityEdge{
+				ID:         uuid.New(),
+				Version:    version,
+				UserA:      a,
+				UserB:      b,
+				Similarity: e.Similarity,
+				Metric:     metric,
+				CreatedAt:  now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, old := range byOther {
+			stale = append(stale, old.ID)
+		}
+		if len(stale) > 0 {
+			if err := tx.Where("id IN ?", stale).Delete(&UserSimilarityEdge{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Bumping the generation locks the version row, so generations are
+		// committed in order.
+		var generation int64
+		if version > 0 {
+			if err := tx.Raw(`
+				UPDATE graph_versions
+				SET edge_count = edge_count + ?, generation = generation + 1
+				WHERE id = ?
+				RETURNING generation
+			`, inserted-len(stale), version).Scan(&generation).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"version", "generation", "updated_at"}),
+		}).Create(&GraphUserUpdate{
+			UserID:     userID,
+			Version:    version,
+			Generation: generation,
+			UpdatedAt:  now,
+		}).Error
+	})
+	return version, err
+}
+
+// replayUserUpdates re-runs UpdateUserGraph for every user updated since
+// since. A full build reads all profiles before it activates, so updates
+// committed meanwhile went to the previous version and are missing from the
+// new one. Records older than since are reflected in the build and dropped.
+func replayUserUpdates(db *gorm.DB, since time.Time) error {
+	var users []uuid.UUID
+	if err := db.Model(&GraphUserUpdate{}).
+		Where("updated_at >= ?", since).
+		Pluck("user_id", &users).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		if err := UpdateUserGraph(db, userID); err != nil {
+			log.Printf("failed to replay graph update for user %s: %v\n", userID, err)
+		}
+	}
+	return db.Where("updated_at < ?", since).Delete(&GraphUserUpdate{}).Error
+}
+
+// orderPair returns the two user IDs in canonical (lexicographic) order so
+// that each unordered pair is stored exactly once.
+func orderPair(a, b string) (string, string) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+// StartPeriodicRebuild starts a full build with the active version's
+// options every interval as a consistency pass over the incrementally
+// maintained edges. A tick is skipped while another build is running.
+func StartPeriodicRebuild(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			opts, err := ActiveBuildOptions(db)
+			if err != nil {
+				log.Println("periodic graph rebuild failed:", err)
+				continue
+			}
+			if _, _, err := StartBuild(db, opts, TriggerScheduler); err != nil {
+				log.Println("periodic graph rebuild skipped:", err)
+			}
+		}
+	}()
+}
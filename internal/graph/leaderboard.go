@@ -0,0 +1,268 @@
+package graph
+
+// leaderboard.go — influence and per-pattern leaderboards
+//
+// Influence leaderboards rank the UserCentrality rows of the active
+// version. Pattern leaderboards rank users by confidence-weighted pattern
+// use, read from PatternActivity: a per-build rollup of submission_patterns
+// by (pattern, user, language, day) over public submissions, so language
+// and time-window filters are cheap range scans and results stay consistent
+// with the version they were built with.
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLeaderboardLimit = 20
+	maxLeaderboardLimit     = 100
+	maxLeaderboardDays      = 3650
+)
+
+// PatternActivity is the rollup row behind pattern leaderboards.
+// Confidence is the sum of detector confidences of the Uses detections.
+type PatternActivity struct {
+	Version    uint      `gorm:"primaryKey;autoIncrement:false"`
+	Pattern    string    `gorm:"primaryKey"`
+	UserID     uuid.UUID `gorm:"primaryKey"`
+	Language   string    `gorm:"primaryKey"`
+	Day        time.Time `gorm:"primaryKey;type:date"`
+	Uses       int       `gorm:"not null"`
+	Confidence float64   `gorm:"not null"`
+}
+
+// storePatternActivity rolls up submission_patterns for a version.
+func storePatternActivity(tx *gorm.DB, version uint) error {
+	return tx.Exec(`
+		INSERT INTO pattern_activities (version, pattern, user_id, language, day, uses, confidence)
+		SELECT ?, ap.name, cs.user_id, cs.language, CAST(cs.created_at AS DATE), COUNT(*), SUM(sp.confidence)
+		FROM code_submissions cs
+		JOIN submission_patterns sp ON sp.submission_id = cs.id
+		JOIN algorithm_patterns ap ON ap.id = sp.pattern_id
+		WHERE cs.visibility = 'public'
+		GROUP BY ap.name, cs.user_id, cs.language, CAST(cs.created_at AS DATE)
+	`, version).Error
+}
+
+type InfluenceEntry struct {
+	Rank           int       `json:"rank"`
+	UserID         uuid.UUID `json:"user_id"`
+	Username       string    `json:"username"`
+	AvatarURL      string    `json:"avatar_url"`
+	PageRank       float64   `json:"pagerank"`
+	Degree         int       `json:"degree"`
+	WeightedDegree float64   `json:"weighted_degree"`
+}
+
+type PatternLeaderEntry struct {
+	Rank      int       `json:"rank"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatar_url"`
+	Uses      int       `json:"uses"`
+	Score     float64   `json:"score"`
+}
+
+type PatternSummary struct {
+	Pattern string `json:"pattern"`
+	Uses    int    `json:"uses"`
+	Users   int    `json:"users"`
+}
+
+// leaderboardQuery holds the shared pagination and filter parameters:
+// limit (default 20, at most 100), offset, language and days (only
+// activity of the last N days).
+type leaderboardQuery struct {
+	limit    int
+	offset   int
+	language string
+	since    *time.Time
+}
+
+func parseLeaderboardQuery(c *gin.Context) (leaderboardQuery, bool) {
+	q := leaderboardQuery{language: c.Query("language")}
+
+	var err error
+	q.limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLeaderboardLimit)))
+	if err != nil || q.limit < 1 || q.limit > maxLeaderboardLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxLeaderboardLimit)})
+		return q, false
+	}
+	q.offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || q.offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return q, false
+	}
+
+	if v := c.Query("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 || days > maxLeaderboardDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and " + strconv.Itoa(maxLeaderboardDays)})
+			return q, false
+		}
+		since := time.Now().AddDate(0, 0, -days)
+		q.since = &since
+	}
+	return q, true
+}
+
+// GetInfluenceLeaderboard handles GET /api/leaderboards/influence.
+// Query parameter by selects pagerank (default) or degree; language and
+// days keep only users with a public submission in that language or window.
+func GetInfluenceLeaderboard(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q, ok := parseLeaderboardQuery(c)
+		if !ok {
+			return
+		}
+
+		order := "uc.page_rank DESC"
+		switch c.DefaultQuery("by", "pagerank") {
+		case "pagerank":
+		case "degree":
+			order = "uc.degree DESC, uc.weighted_degree DESC"
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "by must be pagerank or degree"})
+			return
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		query := db.Table("user_centralities uc").
+			Select("uc.user_id, u.username, u.avatar_url, uc.page_rank, uc.degree, uc.weighted_degree").
+			Joins("JOIN users u ON u.id = uc.user_id").
+			Where("uc.version = ?", version)
+
+		if q.language != "" || q.since != nil {
+			active := db.Table("code_submissions cs").
+				Select("1").
+				Where("cs.user_id = uc.user_id AND cs.visibility = 'public'")
+			if q.language != "" {
+				active = active.Where("cs.language = ?", q.language)
+			}
+			if q.since != nil {
+				active = active.Where("cs.created_at >= ?", *q.since)
+			}
+			query = query.Where("EXISTS (?)", active)
+		}
+
+		entries := []InfluenceEntry{}
+		if err := query.Order(order + ", u.username").
+			Limit(q.limit).
+			Offset(q.offset).
+			Scan(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load leaderboard"})
+			return
+		}
+		for i := range entries {
+			entries[i].Rank = q.offset + i + 1
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"version": version,
+			"entries": entries,
+			"limit":   q.limit,
+			"offset":  q.offset,
+		})
+	}
+}
+
+// ListPatternLeaderboards handles GET /api/leaderboards/patterns and lists
+// every pattern with its total uses and number of users, most used first.
+func ListPatternLeaderboards(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q, ok := parseLeaderboardQuery(c)
+		if !ok {
+			return
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		patterns := []PatternSummary{}
+		if err := filterActivity(db.Table("pattern_activities pa"), version, q).
+			Select("pa.pattern, SUM(pa.uses) AS uses, COUNT(DISTINCT pa.user_id) AS users").
+			Group("pa.pattern").
+			Order("uses DESC, pa.pattern").
+			Limit(q.limit).
+			Offset(q.offset).
+			Scan(&patterns).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load patterns"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"version":  version,
+			"patterns": patterns,
+			"limit":    q.limit,
+			"offset":   q.offset,
+		})
+	}
+}
+
+// GetPatternLeaderboard handles GET /api/leaderboards/patterns/:pattern and
+// ranks users by confidence-weighted uses of the pattern.
+func GetPatternLeaderboard(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q, ok := parseLeaderboardQuery(c)
+		if !ok {
+			return
+		}
+		pattern := c.Param("pattern")
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		entries := []PatternLeaderEntry{}
+		if err := filterActivity(db.Table("pattern_activities pa"), version, q).
+			Select("pa.user_id, u.username, u.avatar_url, SUM(pa.uses) AS uses, SUM(pa.confidence) AS score").
+			Joins("JOIN users u ON u.id = pa.user_id").
+			Where("pa.pattern = ?", pattern).
+			Group("pa.user_id, u.username, u.avatar_url").
+			Order("score DESC, uses DESC, u.username").
+			Limit(q.limit).
+			Offset(q.offset).
+			Scan(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load leaderboard"})
+			return
+		}
+		for i := range entries {
+			entries[i].Rank = q.offset + i + 1
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"version": version,
+			"pattern": pattern,
+			"entries": entries,
+			"limit":   q.limit,
+			"offset":  q.offset,
+		})
+	}
+}
+
+func filterActivity(query *gorm.DB, version uint, q leaderboardQuery) *gorm.DB {
+	query = query.Where("pa.version = ?", version)
+	if q.language != "" {
+		query = query.Where("pa.language = ?", q.language)
+	}
+	if q.since != nil {
+		query = query.Where("pa.day >= ?", q.since.Format("2006-01-02"))
+	}
+	return query
+}
@@ -0,0 +1,349 @@
+package graph
+
+// lsh.go — locality-sensitive candidate generation
+//
+// BuildSimilarityGraph used to compare every pair of profiles, which is
+// O(n²) calls to WeightedJaccard. For large populations we instead:
+//
+//  1. Reduce each profile to a signature vector: its pattern and problem
+//     features weighted by ln(N/df). Languages and complexity classes are
+//     left out; a handful of values covers the whole population, so any
+//     pair would collide on them.
+//  2. Compute a weighted MinHash signature per vector using Improved
+//     Consistent Weighted Sampling (Ioffe, "Improved Consistent Sampling,
+//     Weighted Minhash and L1 Sketching", 2010). For two weight vectors the
+//     probability that a signature slot collides equals their weighted
+//     Jaccard similarity Σmin/Σmax.
+//  3. Split each signature into Bands of Rows slots and bucket profiles by
+//     band. Any two profiles sharing a bucket in at least one band become a
+//     candidate pair; a pair whose signature vectors have similarity s is
+//     found with probability 1-(1-s^Rows)^Bands.
+//  4. Run the exact metric on the full profile vectors of candidate pairs
+//     only.
+//
+// A pair is therefore found when it is similar on its distinctive features.
+// Pairs that reach the threshold only through shared languages, complexity
+// classes or near-universal patterns are not reported by large builds.
+//
+// Thresholds too low for two-row bands use an exact prefix-filtered
+// inverted index over the same signature vectors instead.
+//
+// All randomness is derived from hashes of (slot, feature) so signatures are
+// reproducible across runs and processes.
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LSHParams configures signature length and banding.
+type LSHParams struct {
+	Bands int
+	Rows  int
+	Seed  uint64
+}
+
+const (
+	// lshSignatureSize is the number of MinHash slots used when parameters
+	// are derived from a threshold.
+	lshSignatureSize = 128
+
+	// lshMaxSignatureSize bounds the longer signatures used for thresholds
+	// that lshSignatureSize slots can only cover with one-row bands.
+	lshMaxSignatureSize = 640
+
+	// lshTargetRecall is the minimum probability of finding a pair whose
+	// similarity is exactly at the threshold.
+	lshTargetRecall = 0.95
+
+	// lshMinProfiles is the population size below which brute force is
+	// cheaper than building an index.
+	lshMinProfiles = 500
+)
+
+// ParamsForThreshold picks the largest band width (and therefore the fewest
+// spurious candidates) that still finds a pair at the threshold with
+// probability ≥ lshTargetRecall within lshSignatureSize slots. Thresholds
+// that would need one-row bands, where a single shared slot makes a
+// candidate, get two-row bands over a longer signature instead. Rows is 1
+// only when even lshMaxSignatureSize slots are not enough; candidatePairs
+// then uses the inverted index.
+func ParamsForThreshold(threshold float64) LSHParams {
+	for rows := lshSignatureSize; rows >= 2; rows-- {
+		if lshSignatureSize%rows != 0 {
+			continue
+		}
+		bands := lshSignatureSize / rows
+		if CandidateProbability(threshold, bands, rows) >= lshTargetRecall {
+			return LSHParams{Bands: bands, Rows: rows, Seed: 1}
+		}
+	}
+
+	// 1-(1-s²)^bands ≥ lshTargetRecall
+	if s2 := threshold * threshold; s2 > 0 && s2 < 1 {
+		bands := int(math.Ceil(math.Log(1-lshTargetRecall) / math.Log(1-s2)))
+		if 2*bands <= lshMaxSignatureSize {
+			return LSHParams{Bands: bands, Rows: 2, Seed: 1}
+		}
+	}
+	return LSHParams{Bands: lshSignatureSize, Rows: 1, Seed: 1}
+}
+
+// CandidateProbability is the probability that a pair with similarity s
+// shares at least one band: 1-(1-s^rows)^bands.
+func CandidateProbability(s float64, bands, rows int) float64 {
+	return 1 - math.Pow(1-math.Pow(s, float64(rows)), float64(bands))
+}
+
+// weightedMinHash computes an ICWS signature of length bands*rows. Each
+// slot encodes the sampled (feature, quantised weight) pair as one hash.
+func weightedMinHash(weights map[string]float64, p LSHParams) []uint64 {
+	size := p.Bands * p.Rows
+	sig := make([]uint64, size)
+
+	type feature struct {
+		hash uint64
+		logW float64
+	}
+	features := make([]feature, 0, len(weights))
+	for key, w := range weights {
+		if w > 0 {
+			features = append(features, feature{hashString(key), math.Log(w)})
+		}
+	}
+	if len(features) == 0 {
+		return sig // empty profile: every slot stays zero
+	}
+
+	for slot := 0; slot < size; slot++ {
+		// a = c / (y·e^r) is compared through its logarithm, which picks
+		// the same feature without two exponentials per pair.
+		bestLogA := math.Inf(1)
+		var bestHash uint64
+		var bestT int64
+
+		for _, f := range features {
+			base := p.Seed ^ uint64(slot)*0x9e3779b97f4a7c15 ^ f.hash
+
+			r := gamma21(base, 1)
+			c := gamma21(base, 3)
+			beta := unitFloat(splitmix(base + 5))
+
+			t := math.Floor(f.logW/r + beta)
+			logA := math.Log(c) - r*(t-beta) - r
+
+			if logA < bestLogA {
+				bestLogA, bestHash, bestT = logA, f.hash, int64(t)
+			}
+		}
+		sig[slot] = bestHash ^ splitmix(uint64(bestT))
+	}
+	return sig
+}
+
+// lshCandidates returns candidate index pairs (i < j) whose signatures
+// collide in at least one band.
+func lshCandidates(signatures [][]uint64, p LSHParams) [][2]int {
+	n := len(signatures)
+
+	bandKeys := make([][]uint64, n)
+	for i, sig := range signatures {
+		keys := make([]uint64, p.Bands)
+		for b := 0; b < p.Bands; b++ {
+			h := uint64(14695981039346656037) // FNV-1a offset basis
+			for r := 0; r < p.Rows; r++ {
+				h ^= sig[b*p.Rows+r]
+				h *= 1099511628211
+			}
+			keys[b] = h
+		}
+		bandKeys[i] = keys
+	}
+
+	buckets := make([]map[uint64][]int, p.Bands)
+	for b := range buckets {
+		buckets[b] = map[uint64][]int{}
+		for i := range signatures {
+			buckets[b][bandKeys[i][b]] = append(buckets[b][bandKeys[i][b]], i)
+		}
+	}
+
+	// seen[j] == i+1 marks j as already paired with i, which dedupes pairs
+	// found in several bands without allocating a pair set.
+	seen := make([]int, n)
+	pairs := [][2]int{}
+
+	for i := 0; i < n; i++ {
+		for b := 0; b < p.Bands; b++ {
+			for _, j := range buckets[b][bandKeys[i][b]] {
+				if j <= i || seen[j] == i+1 {
+					continue
+				}
+				seen[j] = i + 1
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// lshSimilarityGraph is bruteForceSimilarityGraph restricted to the
+// candidate pairs of candidatePairs. Pairs below the threshold are never
+// reported.
+func lshSimilarityGraph(profiles []UserPatternProfile, vectors []map[string]float64, metric SimilarityMetric, stats CorpusStats, threshold float64, progress *BuildProgress) []UserSimilarity {
+	candidates := candidatePairs(profiles, stats, threshold)
+	progress.addPairs(len(candidates))
+
+	edges := []UserSimilarity{}
+	for _, pair := range candidates {
+		if progress.aborted() {
+			break
+		}
+		i, j := pair[0], pair[1]
+		score := metric.Compare(vectors[i], vectors[j])
+		if score >= threshold {
+			ua, ub := orderPair(profiles[i].UserID, profiles[j].UserID)
+			edges = append(edges, UserSimilarity{UserA: ua, UserB: ub, Similarity: score})
+		}
+	}
+	return edges
+}
+
+// candidatePairs returns the index pairs (i < j) worth scoring at
+// threshold, chosen on signature vectors by banded MinHash or, when
+// ParamsForThreshold cannot avoid one-row bands, by prefixCandidates.
+func candidatePairs(profiles []UserPatternProfile, stats CorpusStats, threshold float64) [][2]int {
+	idf := signatureIDF(stats)
+	vectors := make([]map[string]float64, len(profiles))
+	for i, p := range profiles {
+		vectors[i] = signatureVector(p.Vector(), idf)
+	}
+
+	params := ParamsForThreshold(threshold)
+	if params.Rows == 1 {
+		return prefixCandidates(vectors, stats, threshold)
+	}
+
+	signatures := make([][]uint64, len(vectors))
+	for i, v := range vectors {
+		signatures[i] = weightedMinHash(v, params)
+	}
+	return lshCandidates(signatures, params)
+}
+
+// signatureIDF returns ln(N/df) for every feature of the corpus. Features
+// used by every profile get zero weight and drop out of signatures.
+func signatureIDF(stats CorpusStats) map[string]float64 {
+	idf := make(map[string]float64, len(stats.DocumentFrequency))
+	for k, df := range stats.DocumentFrequency {
+		if df > 0 && df < stats.Profiles {
+			idf[k] = math.Log(float64(stats.Profiles) / float64(df))
+		}
+	}
+	return idf
+}
+
+// signatureVector keeps the pattern and problem features of a profile
+// vector, weighted by idf. Languages and complexity classes are shared by
+// large parts of the population and would make nearly every pair collide,
+// so they never take part in candidate generation.
+func signatureVector(v map[string]float64, idf map[string]float64) map[string]float64 {
+	out := map[string]float64{}
+	for k, x := range v {
+		if x <= 0 || strings.HasPrefix(k, LanguageFeaturePrefix) || strings.HasPrefix(k, ComplexityFeaturePrefix) {
+			continue
+		}
+		if w := idf[k]; w > 0 {
+			out[k] = x * w
+		}
+	}
+	return out
+}
+
+// prefixCandidates is an inverted index with prefix filtering (Bayardo et
+// al., "Scaling Up All Pairs Similarity Search", 2007). Features are
+// ordered rarest first and each vector indexes only the shortest prefix
+// whose remaining suffix holds less than threshold of its mass. Two vectors
+// with weighted Jaccard ≥ threshold must share an indexed feature, and the
+// common features that would pair almost everyone sit in the unindexed
+// suffixes.
+func prefixCandidates(vectors []map[string]float64, stats CorpusStats, threshold float64) [][2]int {
+	rarer := func(a, b string) bool {
+		da, db := stats.DocumentFrequency[a], stats.DocumentFrequency[b]
+		if da != db {
+			return da < db
+		}
+		return a < b
+	}
+
+	index := map[string][]int{}
+	prefixes := make([][]string, len(vectors))
+	for i, v := range vectors {
+		keys := make([]string, 0, len(v))
+		mass := 0.0
+		for k, x := range v {
+			keys = append(keys, k)
+			mass += x
+		}
+		sort.Slice(keys, func(a, b int) bool { return rarer(keys[a], keys[b]) })
+
+		// Drop features from the common end while the dropped mass stays
+		// below threshold·mass.
+		end, suffix := len(keys), 0.0
+		for end > 0 && suffix+v[keys[end-1]] < threshold*mass {
+			end--
+			suffix += v[keys[end]]
+		}
+		prefixes[i] = keys[:end]
+		for _, k := range prefixes[i] {
+			index[k] = append(index[k], i)
+		}
+	}
+
+	seen := make([]int, len(vectors))
+	pairs := [][2]int{}
+	for i, prefix := range prefixes {
+		for _, k := range prefix {
+			for _, j := range index[k] {
+				if j <= i || seen[j] == i+1 {
+					continue
+				}
+				seen[j] = i + 1
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// ── deterministic pseudo-randomness ─────────────────────────────────────────
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// splitmix is the SplitMix64 finaliser; it turns any 64-bit input into a
+// well-mixed 64-bit output.
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// unitFloat maps a hash to the open interval (0, 1).
+func unitFloat(x uint64) float64 {
+	return (float64(x>>11) + 0.5) / (1 << 53)
+}
+
+// gamma21 draws a Gamma(2,1) variate as -ln(u1·u2) from two derived
+// uniforms.
+func gamma21(base uint64, offset uint64) float64 {
+	u1 := unitFloat(splitmix(base + offset))
+	u2 := unitFloat(splitmix(base + offset + 1))
+	return -math.Log(u1 * u2)
+}
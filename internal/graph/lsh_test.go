@@ -0,0 +1,318 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// syntheticPatterns is the pattern vocabulary used by syntheticProfiles;
+// the names match those produced by the analysis detector.
+var syntheticPatterns = []string{
+	"Nested Loop", "Sequential Loops", "Loop", "Recursion", "Binary Search",
+	"Sorting", "Hashing", "DFS/BFS", "Divide and Conquer",
+	"Dynamic Programming (Memoization)", "Dynamic Programming (Tabulation)",
+	"Early Break Optimization",
+}
+
+// syntheticLanguages and syntheticComplexities are drawn with the given
+// cumulative probabilities, so a few values cover most submissions as they
+// do in practice.
+var (
+	syntheticLanguages     = []string{"python", "java", "cpp", "go", "javascript"}
+	syntheticLanguageCDF   = []float64{0.4, 0.65, 0.85, 0.95, 1}
+	syntheticComplexities  = []string{"O(n)", "O(n^2)", "O(n log n)", "O(1)", "O(log n)"}
+	syntheticComplexityCDF = []float64{0.4, 0.65, 0.8, 0.9, 1}
+)
+
+func drawCDF(rng *rand.Rand, values []string, cdf []float64) string {
+	x := rng.Float64()
+	for i, c := range cdf {
+		if x < c {
+			return values[i]
+		}
+	}
+	return values[len(values)-1]
+}
+
+// syntheticProfiles generates a reproducible population of n users split
+// into clusters. Each user has a handful of submissions; every submission
+// adds its language, complexity class, patterns and problem to the profile
+// with the default feature weights. Users in the same cluster draw most of
+// their patterns and problems from the cluster's preferred set, so the
+// population has near-duplicate pairs, a large unrelated background and
+// the dense language, complexity and "Loop" features real profiles have.
+func syntheticProfiles(n int, seed int64) []UserPatternProfile {
+	rng := rand.New(rand.NewSource(seed))
+	weights := FeatureWeights{Patterns: 1, Problems: 1, Complexity: 0.5, Language: 0.25}
+
+	problems := make([]string, 200)
+	for i := range problems {
+		problems[i] = fmt.Sprintf("%sp%03d", ProblemFeaturePrefix, i)
+	}
+
+	type cluster struct{ patterns, problems []string }
+	clusters := make([]cluster, n/50+1)
+	for c := range clusters {
+		for i := 2 + rng.Intn(3); i > 0; i-- {
+			clusters[c].patterns = append(clusters[c].patterns, syntheticPatterns[rng.Intn(len(syntheticPatterns))])
+		}
+		for i := 3 + rng.Intn(4); i > 0; i-- {
+			clusters[c].problems = append(clusters[c].problems, problems[rng.Intn(len(problems))])
+		}
+	}
+
+	profiles := make([]UserPatternProfile, n)
+	for i := range profiles {
+		pref := clusters[rng.Intn(len(clusters))]
+		language := drawCDF(rng, syntheticLanguages, syntheticLanguageCDF)
+
+		counts := map[string]int{}
+		features := map[string]float64{}
+		for s := 2 + rng.Intn(9); s > 0; s-- {
+			lang := language
+			if rng.Float64() < 0.2 {
+				lang = drawCDF(rng, syntheticLanguages, syntheticLanguageCDF)
+			}
+			features[LanguageFeaturePrefix+lang] += weights.Language
+			features[ComplexityFeaturePrefix+drawCDF(rng, syntheticComplexities, syntheticComplexityCDF)] += weights.Complexity
+
+			patterns := []string{}
+			if rng.Float64() < 0.7 {
+				patterns = append(patterns, "Loop")
+			}
+			for k := 1 + rng.Intn(2); k > 0; k-- {
+				if rng.Float64() < 0.8 {
+					patterns = append(patterns, pref.patterns[rng.Intn(len(pref.patterns))])
+				} else {
+					patterns = append(patterns, syntheticPatterns[rng.Intn(len(syntheticPatterns))])
+				}
+			}
+			for _, name := range patterns {
+				counts[name]++
+				features[name] += weights.Patterns
+			}
+
+			problem := problems[rng.Intn(len(problems))]
+			if rng.Float64() < 0.7 {
+				problem = pref.problems[rng.Intn(len(pref.problems))]
+			}
+			counts[problem]++
+			features[problem] += weights.Problems
+		}
+
+		profiles[i] = UserPatternProfile{
+			UserID:   fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+			Patterns: counts,
+			Features: features,
+		}
+	}
+	return profiles
+}
+
+// fittedVectors fits metric on profiles and returns their vectors.
+func fittedVectors(t testing.TB, name string, profiles []UserPatternProfile) (SimilarityMetric, CorpusStats, []map[string]float64) {
+	t.Helper()
+	metric, err := NewMetric(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := NewCorpusStats(profiles)
+	metric.Fit(stats)
+	return metric, stats, profileVectors(profiles, metric)
+}
+
+func TestParamsForThreshold(t *testing.T) {
+	for _, threshold := range []float64{DefaultThreshold, 0.15, 0.2, 0.3, 0.5, 0.8, 1} {
+		p := ParamsForThreshold(threshold)
+		if p.Rows < 2 {
+			t.Errorf("threshold %.2f: one-row bands", threshold)
+		}
+		if p.Bands*p.Rows > lshMaxSignatureSize {
+			t.Errorf("threshold %.2f: %d bands × %d rows exceed %d slots", threshold, p.Bands, p.Rows, lshMaxSignatureSize)
+		}
+		if got := CandidateProbability(threshold, p.Bands, p.Rows); got < lshTargetRecall {
+			t.Errorf("threshold %.2f: candidate probability %.4f below %.2f", threshold, got, lshTargetRecall)
+		}
+	}
+
+	if p := ParamsForThreshold(0.05); p.Rows != 1 {
+		t.Errorf("threshold 0.05: %d rows, want 1 (inverted index)", p.Rows)
+	}
+}
+
+func TestWeightedMinHashDeterministic(t *testing.T) {
+	p := ParamsForThreshold(DefaultThreshold)
+	v := map[string]float64{"Loop": 2, "Hashing": 0.5, "problem:p001": 1}
+
+	a, b := weightedMinHash(v, p), weightedMinHash(v, p)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("slot %d differs between runs", i)
+		}
+	}
+	for i, x := range weightedMinHash(map[string]float64{}, p) {
+		if x != 0 {
+			t.Fatalf("empty profile has non-zero slot %d", i)
+		}
+	}
+}
+
+func TestSignatureVector(t *testing.T) {
+	stats := CorpusStats{Profiles: 100, DocumentFrequency: map[string]int{
+		"Loop": 100, "Hashing": 10, "problem:p001": 1,
+		"lang:go": 5, "complexity:O(n)": 5,
+	}}
+	v := map[string]float64{
+		"Loop": 3, "Hashing": 2, "problem:p001": 1,
+		"lang:go": 0.25, "complexity:O(n)": 0.5, "Sorting": 1,
+	}
+
+	got := signatureVector(v, signatureIDF(stats))
+	want := map[string]float64{"Hashing": 2 * math.Log(10), "problem:p001": math.Log(100)}
+	if len(got) != len(want) {
+		t.Fatalf("signature vector = %v, want %v", got, want)
+	}
+	for k, w := range want {
+		if math.Abs(got[k]-w) > 1e-9 {
+			t.Errorf("%s = %v, want %v", k, got[k], w)
+		}
+	}
+}
+
+// signatureEdges keeps the edges whose signature vectors have weighted
+// Jaccard at or above threshold: the pairs the index promises to find.
+func signatureEdges(profiles []UserPatternProfile, stats CorpusStats, edges []UserSimilarity, threshold float64) map[[2]string]bool {
+	idf := signatureIDF(stats)
+	vectors := map[string]map[string]float64{}
+	for _, p := range profiles {
+		vectors[p.UserID] = signatureVector(p.Vector(), idf)
+	}
+
+	out := map[[2]string]bool{}
+	for _, e := range edges {
+		if GeneralizedJaccard(vectors[e.UserA], vectors[e.UserB]) >= threshold {
+			out[[2]string{e.UserA, e.UserB}] = true
+		}
+	}
+	return out
+}
+
+// TestLSHRecall checks that the indexed build finds at least
+// lshTargetRecall of the brute-force edges that are also similar on their
+// signature vectors (all of them when the exact inverted index is used),
+// and never reports an edge brute force does not.
+func TestLSHRecall(t *testing.T) {
+	sizes := []int{500, 1000}
+	if testing.Short() {
+		sizes = []int{500}
+	}
+
+	for _, name := range MetricNames() {
+		for _, n := range sizes {
+			for _, threshold := range []float64{0.05, DefaultThreshold, 0.3, 0.5} {
+				target := lshTargetRecall
+				if ParamsForThreshold(threshold).Rows == 1 {
+					target = 1
+				}
+				t.Run(fmt.Sprintf("%s/n=%d/t=%.2f", name, n, threshold), func(t *testing.T) {
+					profiles := syntheticProfiles(n, 42)
+					metric, stats, vectors := fittedVectors(t, name, profiles)
+
+					exact := bruteForceSimilarityGraph(profiles, vectors, metric, threshold, nil)
+					found := lshSimilarityGraph(profiles, vectors, metric, stats, threshold, nil)
+
+					all := map[[2]string]bool{}
+					for _, e := range exact {
+						all[[2]string{e.UserA, e.UserB}] = true
+					}
+					want := signatureEdges(profiles, stats, exact, threshold)
+					if len(want) == 0 {
+						t.Fatal("synthetic population has no signature edges at this threshold")
+					}
+
+					hits := 0
+					for _, e := range found {
+						pair := [2]string{e.UserA, e.UserB}
+						if !all[pair] {
+							t.Fatalf("index reported %s-%s (%.3f) which brute force did not", e.UserA, e.UserB, e.Similarity)
+						}
+						if want[pair] {
+							hits++
+						}
+					}
+					if recall := float64(hits) / float64(len(want)); recall < target {
+						t.Errorf("recall %.4f (%d/%d) below %.2f", recall, hits, len(want), target)
+					}
+				})
+			}
+		}
+	}
+}
+
+// maxCandidateFraction bounds the share of all pairs scored at the default
+// threshold. One-row bands over full vectors made every pair sharing a
+// language a candidate, i.e. all of them.
+const maxCandidateFraction = 0.4
+
+func TestCandidateFraction(t *testing.T) {
+	n := 2000
+	if testing.Short() {
+		n = 500
+	}
+	profiles := syntheticProfiles(n, 42)
+	stats := NewCorpusStats(profiles)
+
+	for _, p := range profiles[:10] {
+		if !hasPrefix(p.Features, LanguageFeaturePrefix) || !hasPrefix(p.Features, ComplexityFeaturePrefix) {
+			t.Fatalf("synthetic profile %s lacks language or complexity features", p.UserID)
+		}
+	}
+
+	pairs := n * (n - 1) / 2
+	got := len(candidatePairs(profiles, stats, DefaultThreshold))
+	if fraction := float64(got) / float64(pairs); fraction > maxCandidateFraction {
+		t.Errorf("%d of %d pairs (%.3f) are candidates, want at most %.2f", got, pairs, fraction, maxCandidateFraction)
+	}
+}
+
+func hasPrefix(features map[string]float64, prefix string) bool {
+	for k := range features {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var benchmarkSizes = []int{500, 1000, 2000, 5000}
+
+func BenchmarkLSHCandidates(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			profiles := syntheticProfiles(n, 42)
+			metric, stats, vectors := fittedVectors(b, MetricWeightedJaccard, profiles)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lshSimilarityGraph(profiles, vectors, metric, stats, DefaultThreshold, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkBruteForce(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			profiles := syntheticProfiles(n, 42)
+			metric, _, vectors := fittedVectors(b, MetricWeightedJaccard, profiles)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bruteForceSimilarityGraph(profiles, vectors, metric, DefaultThreshold, nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package graph
+
+// mentor.go — complementary ("mentor") recommendations
+//
+// Similarity recommendations surface people who already know what you know.
+// A mentor is instead someone who shares some common ground with you but is
+// strong where you are weak. For user U and candidate C, with pattern
+// strength s(p) = ln(1 + count):
+//
+//	baseline = |shared patterns| / |U's patterns|
+//	coverage = Σ s_C(p) over C's patterns that are gaps for U / Σ s_C(p)
+//	score    = baseline · coverage
+//
+// A pattern is a gap when C has used it at least mentorMinCount times and U
+// has used it less than half as often as C ("weak") or never ("missing").
+// Candidates without a single shared pattern are skipped: without common
+// ground the gap list is just "everything".
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"devgraph/internal/connection"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// mentorMinCount is how often a candidate must have used a pattern for
+	// it to count as one of their strengths.
+	mentorMinCount = 2
+
+	// maxMentorCandidates is how many users sharing the most patterns with
+	// the caller are considered per request.
+	maxMentorCandidates = 200
+
+	maxMentorLimit = 20
+)
+
+const (
+	GapMissing = "missing"
+	GapWeak    = "weak"
+)
+
+type GapFill struct {
+	Pattern    string `json:"pattern"`
+	Kind       string `json:"kind"`
+	YourCount  int    `json:"your_count"`
+	TheirCount int    `json:"their_count"`
+}
+
+type MentorRecommendation struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Username       string    `json:"username"`
+	Score          float64   `json:"score"`
+	Baseline       float64   `json:"baseline"`
+	Coverage       float64   `json:"coverage"`
+	SharedPatterns []string  `json:"shared_patterns"`
+	FillsGaps      []GapFill `json:"fills_gaps"`
+}
+
+// patternCounts returns only the pattern entries of a profile, dropping
+// solved-problem features.
+func patternCounts(p UserPatternProfile) map[string]int {
+	out := map[string]int{}
+	for k, c := range p.Patterns {
+		if !strings.HasPrefix(k, ProblemFeaturePrefix) && c > 0 {
+			out[k] = c
+		}
+	}
+	return out
+}
+
+func patternNames(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for k := range counts {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RankMentors scores every other profile as a mentor for userID and returns
+// the best limit candidates, strongest first. Profiles of excluded users are
+// skipped.
+func RankMentors(profiles []UserPatternProfile, userID string, excluded []uuid.UUID, limit int) []MentorRecommendation {
+	skip := make(map[string]bool, len(excluded))
+	for _, id := range excluded {
+		skip[id.String()] = true
+	}
+
+	var self map[string]int
+	for _, p := range profiles {
+		if p.UserID == userID {
+			self = patternCounts(p)
+			break
+		}
+	}
+	if len(self) == 0 {
+		return []MentorRecommendation{}
+	}
+	selfNames := patternNames(self)
+
+	out := []MentorRecommendation{}
+	for _, p := range profiles {
+		if p.UserID == userID || skip[p.UserID] {
+			continue
+		}
+		other := patternCounts(p)
+
+		shared := findSharedPatterns(selfNames, patternNames(other))
+		if len(shared) == 0 {
+			continue
+		}
+
+		total, gapMass := 0.0, 0.0
+		gaps := []GapFill{}
+		for name, theirs := range other {
+			strength := math.Log1p(float64(theirs))
+			total += strength
+
+			mine := self[name]
+			if theirs < mentorMinCount || 2*mine >= theirs {
+				continue
+			}
+			kind := GapWeak
+			if mine == 0 {
+				kind = GapMissing
+			}
+			gapMass += strength
+			gaps = append(gaps, GapFill{Pattern: name, Kind: kind, YourCount: mine, TheirCount: theirs})
+		}
+		if len(gaps) == 0 || total == 0 {
+			continue
+		}
+
+		baseline := float64(len(shared)) / float64(len(self))
+		coverage := gapMass / total
+
+		sort.Slice(gaps, func(i, j int) bool {
+			if gaps[i].TheirCount != gaps[j].TheirCount {
+				return gaps[i].TheirCount > gaps[j].TheirCount
+			}
+			return gaps[i].Pattern < gaps[j].Pattern
+		})
+
+		id, err := uuid.Parse(p.UserID)
+		if err != nil {
+			continue
+		}
+		out = append(out, MentorRecommendation{
+			UserID:         id,
+			Score:          baseline * coverage,
+			Baseline:       baseline,
+			Coverage:       coverage,
+			SharedPatterns: shared,
+			FillsGaps:      gaps,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].UserID.String() < out[j].UserID.String()
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// GetMentorRecommendations handles GET /api/recommendations/mentors.
+// Query parameter: limit (default 5, at most 20). Connected, pending and
+// blocked users are never suggested.
+func GetMentorRecommendations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+		if err != nil || limit < 1 || limit > maxMentorLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxMentorLimit)})
+			return
+		}
+
+		// Only users sharing a pattern with the caller can be mentors. The
+		// ones sharing the most are ranked in SQL and only their profiles
+		// are built, with the active version's weights.
+		opts, err := ActiveBuildOptions(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph options"})
+			return
+		}
+		candidates, err := candidateNeighbours(db, userID, FeatureWeights{Patterns: 1}, maxMentorCandidates)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load candidates"})
+			return
+		}
+		profiles, err := BuildProfilesFor(db, append(candidates, userID.String()), opts.Weights)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build profiles"})
+			return
+		}
+
+		excluded, err := connection.ExcludedUsers(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load connections"})
+			return
+		}
+
+		mentors := RankMentors(profiles, userID.String(), excluded, limit)
+
+		ids := make([]uuid.UUID, len(mentors))
+		for i, m := range mentors {
+			ids[i] = m.UserID
+		}
+		names := usernames(db, ids)
+		for i := range mentors {
+			mentors[i].Username = names[mentors[i].UserID]
+		}
+
+		c.JSON(http.StatusOK, mentors)
+	}
+}
@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRankMentorsSkipsExcluded(t *testing.T) {
+	profile := func(n int, patterns map[string]int) UserPatternProfile {
+		return UserPatternProfile{UserID: testUser(n).String(), Patterns: patterns}
+	}
+	profiles := []UserPatternProfile{
+		profile(1, map[string]int{"Loop": 3}),
+		profile(2, map[string]int{"Loop": 2, "Recursion": 4}),
+		profile(3, map[string]int{"Loop": 1, "Hashing": 3}),
+		profile(4, map[string]int{"Sorting": 5}),
+	}
+
+	tests := []struct {
+		name     string
+		excluded []uuid.UUID
+		want     []int
+	}{
+		{"none", nil, []int{3, 2}},
+		{"one excluded", []uuid.UUID{testUser(2)}, []int{3}},
+		{"all excluded", []uuid.UUID{testUser(2), testUser(3)}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankMentors(profiles, testUser(1).String(), tt.excluded, 5)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d mentors, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, n := range tt.want {
+				if got[i].UserID != testUser(n) {
+					t.Errorf("mentor %d = %v, want user %d", i, got[i].UserID, n)
+				}
+			}
+		})
+	}
+}
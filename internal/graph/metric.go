@@ -0,0 +1,214 @@
+package graph
+
+// metric.go — pluggable similarity metrics
+//
+// A SimilarityMetric turns each profile into a weighted feature vector and
+// compares two such vectors. Vectors are computed once per profile per
+// build, so the pairwise loop only does map lookups.
+//
+// Fit is called with the population's CorpusStats before any Vector call;
+// metrics that weight features by how rare they are (TF-IDF, BM25) derive
+// their weights there. This matters because near-universal patterns such as
+// "Loop" or "Hashing" otherwise dominate every comparison.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type SimilarityMetric interface {
+	// Name is the stable identifier recorded on graph versions and edges.
+	Name() string
+	// Fit sets the population statistics. It must be called before Vector.
+	Fit(stats CorpusStats)
+	// Vector returns the weighted feature vector of a profile.
+	Vector(p UserPatternProfile) map[string]float64
+	// Compare scores two vectors produced by Vector, in [0, 1].
+	Compare(a, b map[string]float64) float64
+}
+
+const (
+	MetricWeightedJaccard = "weighted_jaccard"
+	MetricCosineTFIDF     = "cosine_tfidf"
+	MetricBM25            = "bm25"
+)
+
+// NewMetric returns a fresh, unfitted metric by name.
+func NewMetric(name string) (SimilarityMetric, error) {
+	switch name {
+	case "", MetricWeightedJaccard:
+		return &weightedJaccardMetric{}, nil
+	case MetricCosineTFIDF:
+		return &tfidfMetric{}, nil
+	case MetricBM25:
+		return &bm25Metric{k1: 1.2, b: 0.75}, nil
+	}
+	return nil, fmt.Errorf("unknown similarity metric %q", name)
+}
+
+// MetricNames lists the registered metric names.
+func MetricNames() []string {
+	names := []string{MetricWeightedJaccard, MetricCosineTFIDF, MetricBM25}
+	sort.Strings(names)
+	return names
+}
+
+// ── weighted Jaccard ────────────────────────────────────────────────────────
+
+// weightedJaccardMetric is the original metric: Σmin/Σmax over the profile
+// feature vector.
+type weightedJaccardMetric struct{}
+
+func (m *weightedJaccardMetric) Name() string { return MetricWeightedJaccard }
+
+func (m *weightedJaccardMetric) Fit(CorpusStats) {}
+
+func (m *weightedJaccardMetric) Vector(p UserPatternProfile) map[string]float64 {
+	return p.Vector()
+}
+
+func (m *weightedJaccardMetric) Compare(a, b map[string]float64) float64 {
+	return GeneralizedJaccard(a, b)
+}
+
+// ── cosine over TF-IDF ──────────────────────────────────────────────────────
+
+// tfidfMetric weights each feature by ln(1 + tf) · idf, with the smoothed
+// idf = ln((1+N)/(1+df)) + 1, and compares vectors by cosine similarity.
+type tfidfMetric struct {
+	idf map[string]float64
+	n   int
+}
+
+func (m *tfidfMetric) Name() string { return MetricCosineTFIDF }
+
+func (m *tfidfMetric) Fit(stats CorpusStats) {
+	m.n = stats.Profiles
+	m.idf = make(map[string]float64, len(stats.DocumentFrequency))
+	for k, d := range stats.DocumentFrequency {
+		m.idf[k] = math.Log(float64(1+m.n)/float64(1+d)) + 1
+	}
+}
+
+func (m *tfidfMetric) Vector(p UserPatternProfile) map[string]float64 {
+	out := map[string]float64{}
+	for k, tf := range p.Vector() {
+		if tf <= 0 {
+			continue
+		}
+		idf, ok := m.idf[k]
+		if !ok {
+			idf = math.Log(float64(1+m.n)) + 1 // unseen feature: df = 0
+		}
+		out[k] = math.Log1p(tf) * idf
+	}
+	return out
+}
+
+func (m *tfidfMetric) Compare(a, b map[string]float64) float64 {
+	return Cosine(a, b)
+}
+
+// ── normalised BM25 ─────────────────────────────────────────────────────────
+
+// bm25Metric weights each feature with the Okapi BM25 term weight, treating
+// a profile's total feature mass as its document length, then compares the
+// weighted vectors by cosine so the score is symmetric and in [0, 1].
+type bm25Metric struct {
+	k1, b  float64
+	idf    map[string]float64
+	avgLen float64
+	n      int
+}
+
+func (m *bm25Metric) Name() string { return MetricBM25 }
+
+func (m *bm25Metric) Fit(stats CorpusStats) {
+	m.n = stats.Profiles
+	m.idf = make(map[string]float64, len(stats.DocumentFrequency))
+	for k, d := range stats.DocumentFrequency {
+		// Lucene's ln(1 + …) form keeps idf positive even for features
+		// present in more than half of the profiles.
+		m.idf[k] = math.Log(1 + (float64(m.n)-float64(d)+0.5)/(float64(d)+0.5))
+	}
+	m.avgLen = stats.AvgMass
+}
+
+func (m *bm25Metric) Vector(p UserPatternProfile) map[string]float64 {
+	v := p.Vector()
+	length := vectorMass(v)
+	norm := 1.0
+	if m.avgLen > 0 {
+		norm = 1 - m.b + m.b*length/m.avgLen
+	}
+
+	out := map[string]float64{}
+	for k, tf := range v {
+		if tf <= 0 {
+			continue
+		}
+		idf, ok := m.idf[k]
+		if !ok {
+			idf = math.Log(1 + (float64(m.n)+0.5)/0.5)
+		}
+		out[k] = idf * tf * (m.k1 + 1) / (tf + m.k1*norm)
+	}
+	return out
+}
+
+func (m *bm25Metric) Compare(a, b map[string]float64) float64 {
+	return Cosine(a, b)
+}
+
+// ── shared helpers ──────────────────────────────────────────────────────────
+
+func vectorMass(v map[string]float64) float64 {
+	total := 0.0
+	for _, x := range v {
+		total += x
+	}
+	return total
+}
+
+// GeneralizedJaccard is WeightedJaccard for real-valued, non-negative
+// vectors: Σmin(a,b) / Σmax(a,b).
+func GeneralizedJaccard(a, b map[string]float64) float64 {
+	minSum, maxSum := 0.0, 0.0
+	for k, av := range a {
+		bv := b[k]
+		minSum += math.Min(av, bv)
+		maxSum += math.Max(av, bv)
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			maxSum += bv
+		}
+	}
+	if maxSum == 0 {
+		return 0
+	}
+	return minSum / maxSum
+}
+
+// Cosine returns the cosine similarity of two non-negative vectors.
+func Cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	dot := 0.0
+	for k, av := range a {
+		dot += av * b[k]
+	}
+	na, nb := 0.0, 0.0
+	for _, x := range a {
+		na += x * x
+	}
+	for _, x := range b {
+		nb += x * x
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -8,8 +8,81 @@ import (
 
 type UserSimilarityEdge struct {
 	ID         uuid.UUID `gorm:"primaryKey"`
-	UserA      uuid.UUID `gorm:"index"`
-	UserB      uuid.UUID `gorm:"index"`
+	Version    uint      `gorm:"not null;default:0;uniqueIndex:idx_edge_version_pair,priority:1"`
+	UserA      uuid.UUID `gorm:"index;uniqueIndex:idx_edge_version_pair,priority:2"`
+	UserB      uuid.UUID `gorm:"index;uniqueIndex:idx_edge_version_pair,priority:3"`
 	Similarity float64
+	Metric     string `gorm:"default:''"`
 	CreatedAt  time.Time
 }
+
+const (
+	VersionBuilding = "building"
+	VersionActive   = "active"
+	VersionRetired  = "retired"
+)
+
+// GraphVersion is one full similarity-graph build. Exactly one version is
+// active at a time; readers only see edges of the active version. Edges
+// stored before versioning existed carry Version 0 and are served until the
+// first versioned build is activated.
+//
+// Incremental updates (UpdateUserGraph) keep the edges and EdgeCount of the
+// active version current, including a version that was activated again by a
+// rollback. Communities, centrality scores, leaderboards and the pattern
+// graph are derived once by the full build and only refreshed by the next
+// one. Retired versions are never modified.
+type GraphVersion struct {
+	ID        uint    `gorm:"primaryKey"`
+	Status    string  `gorm:"not null;index"`
+	Metric    string  `gorm:"default:''"`
+	Threshold float64 `gorm:"not null;default:0"`
+	// FeatureWeights is the JSON-encoded FeatureWeights used for profiles.
+	FeatureWeights string `gorm:"type:text;default:''"`
+	// Profiles and AvgFeatureMass are the corpus statistics of the build;
+	// per-feature document frequencies are stored as FeatureFrequency rows.
+	Profiles       int     `gorm:"not null;default:0"`
+	AvgFeatureMass float64 `gorm:"not null;default:0"`
+	EdgeCount      int     `gorm:"not null"`
+	// Generation counts the incremental updates applied to the version.
+	Generation  int64 `gorm:"not null;default:0"`
+	CreatedAt   time.Time
+	ActivatedAt *time.Time
+}
+
+// GraphUserUpdate records the last time UpdateUserGraph changed a user's
+// edges: when, so a full build can replay the updates it missed while it
+// ran, and at which version generation, so adjacency caches in every
+// process can reload just that user.
+type GraphUserUpdate struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Version    uint      `gorm:"not null;default:0;index:idx_user_update_generation,priority:1"`
+	Generation int64     `gorm:"not null;default:0;index:idx_user_update_generation,priority:2"`
+	UpdatedAt  time.Time `gorm:"not null;index"`
+}
+
+const (
+	BuildPending = "pending"
+	BuildRunning = "running"
+	BuildDone    = "done"
+	BuildFailed  = "failed"
+)
+
+// BuildJob tracks one asynchronous graph build. Progress counters are
+// flushed periodically while the build runs. TriggeredBy is "scheduler" or
+// "user:<id>".
+type BuildJob struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Status         string    `gorm:"not null;index"`
+	Phase          string    `gorm:"default:''"`
+	Options        string    `gorm:"type:text"`
+	TriggeredBy    string    `gorm:"not null"`
+	ProfilesBuilt  int64     `gorm:"not null;default:0"`
+	PairsEvaluated int64     `gorm:"not null;default:0"`
+	EdgesWritten   int64     `gorm:"not null;default:0"`
+	Version        uint      `gorm:"not null;default:0"`
+	Error          string    `gorm:"default:''"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CompletedAt    *time.Time
+}
@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// BuildOptions selects the metric, edge threshold and profile feature
+// weights of a graph build.
+type BuildOptions struct {
+	Metric    string         `json:"metric"`
+	Threshold float64        `json:"threshold"`
+	Weights   FeatureWeights `json:"weights"`
+}
+
+// DefaultBuildOptions reads GRAPH_METRIC and GRAPH_THRESHOLD, falling back
+// to weighted Jaccard at DefaultThreshold, with DefaultFeatureWeights.
+func DefaultBuildOptions() BuildOptions {
+	opts := BuildOptions{
+		Metric:    MetricWeightedJaccard,
+		Threshold: DefaultThreshold,
+		Weights:   DefaultFeatureWeights(),
+	}
+	if m := os.Getenv("GRAPH_METRIC"); m != "" {
+		opts.Metric = m
+	}
+	if t, err := strconv.ParseFloat(os.Getenv("GRAPH_THRESHOLD"), 64); err == nil {
+		opts.Threshold = t
+	}
+	return opts
+}
+
+// ActiveBuildOptions returns the options the active graph version was built
+// with, so incremental updates and consistency rebuilds stay comparable.
+// Before the first versioned build it returns DefaultBuildOptions.
+func ActiveBuildOptions(db *gorm.DB) (BuildOptions, error) {
+	var v GraphVersion
+	err := db.Where("status = ?", VersionActive).Order("id DESC").Take(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultBuildOptions(), nil
+	}
+	if err != nil {
+		return BuildOptions{}, err
+	}
+	if v.Metric == "" {
+		return DefaultBuildOptions(), nil
+	}
+	opts := BuildOptions{Metric: v.Metric, Threshold: v.Threshold, Weights: DefaultFeatureWeights()}
+	// Versions built before feature weights existed have no recorded
+	// weights and keep the defaults.
+	if v.FeatureWeights != "" {
+		if err := json.Unmarshal([]byte(v.FeatureWeights), &opts.Weights); err != nil {
+			return BuildOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
+// metric validates the options and returns a fresh metric instance.
+func (o BuildOptions) metric() (SimilarityMetric, error) {
+	if o.Threshold <= 0 || o.Threshold > 1 {
+		return nil, errors.New("threshold must be in (0, 1]")
+	}
+	w := o.Weights
+	if w.Patterns < 0 || w.Problems < 0 || w.Complexity < 0 || w.Language < 0 || w.HalfLifeDays < 0 {
+		return nil, errors.New("feature weights must not be negative")
+	}
+	if w.Patterns+w.Problems+w.Complexity+w.Language == 0 {
+		return nil, errors.New("at least one feature weight must be positive")
+	}
+	return NewMetric(o.Metric)
+}
@@ -0,0 +1,263 @@
+package graph
+
+// pattern_graph.go — pattern co-occurrence graph
+//
+// Nodes are algorithm patterns and an edge joins two patterns detected in
+// the same submission. With N submissions in scope, n(p) of them using
+// pattern p and n(p,q) using both p and q, each edge carries
+//
+//	cooccurrence = n(p,q)
+//	lift         = n(p,q) · N / (n(p) · n(q))
+//	pmi          = ln(lift)
+//
+// Lift above 1 (positive PMI) means the two patterns appear together more
+// often than they would if they were independent. The graph is rolled up
+// with every build from public submissions, once per language and once
+// across all languages (stored with language ""), so it stays consistent
+// with the similarity graph version it belongs to.
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	WeightCooccurrence = "cooccurrence"
+	WeightLift         = "lift"
+	WeightPMI          = "pmi"
+
+	defaultPatternMinCount = 2
+)
+
+// PatternFrequency is the number of submissions using a pattern.
+type PatternFrequency struct {
+	Version     uint   `gorm:"primaryKey;autoIncrement:false"`
+	Language    string `gorm:"primaryKey"`
+	Pattern     string `gorm:"primaryKey"`
+	Submissions int    `gorm:"not null"`
+}
+
+// PatternCooccurrence is an edge of the pattern graph with PatternA <
+// PatternB. Submissions is the number of submissions using both.
+type PatternCooccurrence struct {
+	Version     uint    `gorm:"primaryKey;autoIncrement:false"`
+	Language    string  `gorm:"primaryKey"`
+	PatternA    string  `gorm:"primaryKey"`
+	PatternB    string  `gorm:"primaryKey"`
+	Submissions int     `gorm:"not null;index"`
+	Lift        float64 `gorm:"not null"`
+	PMI         float64 `gorm:"column:pmi;not null"`
+}
+
+// weight returns the edge weight of the given kind.
+func (e PatternCooccurrence) weight(kind string) float64 {
+	switch kind {
+	case WeightLift:
+		return e.Lift
+	case WeightPMI:
+		return e.PMI
+	}
+	return float64(e.Submissions)
+}
+
+// patternScope lists the distinct patterns of every public submission, once
+// under its language and once under "" for all languages.
+const patternScope = `
+	WITH detected AS (
+		SELECT DISTINCT cs.id AS submission_id, cs.language, ap.name AS pattern
+		FROM code_submissions cs
+		JOIN submission_patterns sp ON sp.submission_id = cs.id
+		JOIN algorithm_patterns ap ON ap.id = sp.pattern_id
+		WHERE cs.visibility = 'public'
+	),
+	scoped AS (
+		SELECT submission_id, language, pattern FROM detected
+		UNION ALL
+		SELECT submission_id, '' AS language, pattern FROM detected
+	)`
+
+// storePatternGraph computes and stores the pattern graph of a version.
+func storePatternGraph(tx *gorm.DB, version uint) error {
+	if err := tx.Exec(patternScope+`
+		INSERT INTO pattern_frequencies (version, language, pattern, submissions)
+		SELECT ?, language, pattern, COUNT(*)
+		FROM scoped
+		GROUP BY language, pattern
+	`, version).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(patternScope+`,
+	totals AS (
+		SELECT language, COUNT(DISTINCT submission_id) AS n
+		FROM scoped
+		GROUP BY language
+	),
+	freq AS (
+		SELECT language, pattern, COUNT(*) AS n
+		FROM scoped
+		GROUP BY language, pattern
+	),
+	pairs AS (
+		SELECT a.language, a.pattern AS pattern_a, b.pattern AS pattern_b, COUNT(*) AS n
+		FROM scoped a
+		JOIN scoped b ON b.submission_id = a.submission_id AND b.language = a.language AND a.pattern < b.pattern
+		GROUP BY a.language, a.pattern, b.pattern
+	)
+	INSERT INTO pattern_cooccurrences (version, language, pattern_a, pattern_b, submissions, lift, pmi)
+	SELECT ?, language, pattern_a, pattern_b, n, lift, LN(lift)
+	FROM (
+		SELECT p.language, p.pattern_a, p.pattern_b, p.n,
+			CAST(p.n AS DOUBLE PRECISION) * t.n / (fa.n * fb.n) AS lift
+		FROM pairs p
+		JOIN totals t ON t.language = p.language
+		JOIN freq fa ON fa.language = p.language AND fa.pattern = p.pattern_a
+		JOIN freq fb ON fb.language = p.language AND fb.pattern = p.pattern_b
+	) scored
+	`, version).Error
+}
+
+// patternSchema lists the attributes of pattern graph exports.
+var patternSchema = exportSchema{
+	label: "pattern",
+	node:  []exportAttr{{"submissions", "int"}},
+	edge:  []exportAttr{{"submissions", "int"}, {"lift", "double"}, {"pmi", "double"}},
+}
+
+type PatternNodeResponse struct {
+	Pattern     string `json:"pattern"`
+	Submissions int    `json:"submissions"`
+}
+
+type PatternEdgeResponse struct {
+	Source      string  `json:"source"`
+	Target      string  `json:"target"`
+	Submissions int     `json:"submissions"`
+	Lift        float64 `json:"lift"`
+	PMI         float64 `json:"pmi"`
+	Weight      float64 `json:"weight"`
+}
+
+// GetPatternGraph handles GET /api/graph/patterns.
+//
+// Query parameters: language (default all languages), weight
+// (cooccurrence, lift or pmi; default cooccurrence), min_count (default 2)
+// dropping edges seen in fewer submissions, and format (json by default, or
+// any graph export format).
+func GetPatternGraph(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		language := c.Query("language")
+
+		kind := c.DefaultQuery("weight", WeightCooccurrence)
+		switch kind {
+		case WeightCooccurrence, WeightLift, WeightPMI:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weight must be cooccurrence, lift or pmi"})
+			return
+		}
+
+		minCount, err := strconv.Atoi(c.DefaultQuery("min_count", strconv.Itoa(defaultPatternMinCount)))
+		if err != nil || minCount < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_count must be a positive integer"})
+			return
+		}
+
+		format := c.DefaultQuery("format", "json")
+		contentType, ext := ExportContentType(format)
+		if format != "json" && ext == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown format", "formats": append([]string{"json"}, ExportFormats()...)})
+			return
+		}
+
+		version, err := ActiveVersion(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph version"})
+			return
+		}
+
+		var nodes []PatternFrequency
+		if err := db.Where("version = ? AND language = ?", version, language).
+			Order("pattern").
+			Find(&nodes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load patterns"})
+			return
+		}
+
+		var edges []PatternCooccurrence
+		if err := db.Where("version = ? AND language = ? AND submissions >= ?", version, language, minCount).
+			Order("pattern_a, pattern_b").
+			Find(&edges).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load pattern co-occurrences"})
+			return
+		}
+
+		if format == "json" {
+			outNodes := make([]PatternNodeResponse, 0, len(nodes))
+			for _, n := range nodes {
+				outNodes = append(outNodes, PatternNodeResponse{Pattern: n.Pattern, Submissions: n.Submissions})
+			}
+			outEdges := make([]PatternEdgeResponse, 0, len(edges))
+			for _, e := range edges {
+				outEdges = append(outEdges, PatternEdgeResponse{
+					Source:      e.PatternA,
+					Target:      e.PatternB,
+					Submissions: e.Submissions,
+					Lift:        e.Lift,
+					PMI:         e.PMI,
+					Weight:      e.weight(kind),
+				})
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"version":  version,
+				"language": language,
+				"weight":   kind,
+				"nodes":    outNodes,
+				"edges":    outEdges,
+			})
+			return
+		}
+
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="devgraph-patterns`+ext+`"`)
+		c.Status(http.StatusOK)
+
+		if err := writePatternGraph(c.Writer, format, kind, nodes, edges); err != nil {
+			log.Println("pattern graph export failed:", err)
+		}
+	}
+}
+
+func writePatternGraph(w io.Writer, format, kind string, nodes []PatternFrequency, edges []PatternCooccurrence) error {
+	gw, err := newGraphWriter(format, w, patternSchema)
+	if err != nil {
+		return err
+	}
+
+	if err := gw.begin(); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err := gw.node(graphNode{ID: n.Pattern, Label: n.Pattern, Attrs: []interface{}{n.Submissions}}); err != nil {
+			return err
+		}
+	}
+	if err := gw.beginEdges(); err != nil {
+		return err
+	}
+	for _, e := range edges {
+		if err := gw.edge(graphEdge{
+			Source: e.PatternA,
+			Target: e.PatternB,
+			Weight: e.weight(kind),
+			Attrs:  []interface{}{e.Submissions, e.Lift, e.PMI},
+		}); err != nil {
+			return err
+		}
+	}
+	return gw.end()
+}
@@ -0,0 +1,353 @@
+package graph
+
+// pattern_recommend.go — "what to learn next" via item-based collaborative
+// filtering
+//
+// The user×pattern matrix holds r(u, p) = ln(1 + count) for every user.
+// Pattern-pattern similarity is the cosine between two pattern columns, so
+// patterns that tend to be used by the same people are close. It is
+// computed over the full matrix with every build and stored with the
+// version. A pattern q the user has not used yet is scored as
+//
+//	item(q)    = Σ_p sim(q, p)·r(u, p) / Σ_p r(u, p)   over the user's patterns
+//	support(q) = Σ similarity of neighbours using q / Σ neighbour similarity
+//	score(q)   = item(q) · support(q)
+//
+// where the neighbours are the user's strongest edges in the active graph.
+// Candidates are limited to patterns at least one neighbour has used, so
+// recommendations reflect what similar developers actually practise. A user
+// without neighbours gets every unused pattern as a candidate, with its
+// public submission count relative to the most used pattern as support.
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"devgraph/internal/connection"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// cfNeighbours is how many of the user's strongest edges are consulted.
+	cfNeighbours = 20
+
+	// cfExamples is the number of example submissions per recommendation.
+	cfExamples = 3
+
+	maxPatternLimit = 20
+)
+
+type PatternSuggestion struct {
+	Pattern         string           `json:"pattern"`
+	Score           float64          `json:"score"`
+	Because         []string         `json:"because"`
+	NeighboursUsing int              `json:"neighbours_using"`
+	Neighbours      int              `json:"neighbours"`
+	Examples        []PatternExample `json:"examples"`
+	related         map[string]float64
+}
+
+type PatternExample struct {
+	SubmissionID uuid.UUID `json:"submission_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Username     string    `json:"username"`
+	Language     string    `json:"language"`
+}
+
+// PatternSimilarity is the cosine similarity between two pattern columns of
+// the user×pattern matrix, with PatternA < PatternB.
+type PatternSimilarity struct {
+	Version    uint    `gorm:"primaryKey;autoIncrement:false"`
+	PatternA   string  `gorm:"primaryKey"`
+	PatternB   string  `gorm:"primaryKey"`
+	Similarity float64 `gorm:"not null"`
+}
+
+// patternCosines scores every pair of patterns used by a common user.
+const patternCosines = `
+	WITH ratings AS (
+		SELECT cs.user_id, ap.name AS pattern, LN(1 + COUNT(*)) AS r
+		FROM code_submissions cs
+		JOIN submission_patterns sp ON sp.submission_id = cs.id
+		JOIN algorithm_patterns ap ON ap.id = sp.pattern_id
+		GROUP BY cs.user_id, ap.name
+	),
+	norms AS (
+		SELECT pattern, SQRT(SUM(r * r)) AS n
+		FROM ratings
+		GROUP BY pattern
+	),
+	dots AS (
+		SELECT a.pattern AS pattern_a, b.pattern AS pattern_b, SUM(a.r * b.r) AS d
+		FROM ratings a
+		JOIN ratings b ON b.user_id = a.user_id AND a.pattern < b.pattern
+		GROUP BY a.pattern, b.pattern
+	),
+	cosines AS (
+		SELECT d.pattern_a, d.pattern_b, d.d / (na.n * nb.n) AS similarity
+		FROM dots d
+		JOIN norms na ON na.pattern = d.pattern_a
+		JOIN norms nb ON nb.pattern = d.pattern_b
+	)`
+
+// storePatternSimilarities computes and stores the pattern similarities of
+// a version.
+func storePatternSimilarities(tx *gorm.DB, version uint) error {
+	return tx.Exec(patternCosines+`
+		INSERT INTO pattern_similarities (version, pattern_a, pattern_b, similarity)
+		SELECT ?, pattern_a, pattern_b, similarity FROM cosines
+	`, version).Error
+}
+
+// loadPatternSimilarities returns the pattern similarities of version as a
+// symmetric map. Unversioned graphs have none stored, so they are computed
+// on the fly.
+func loadPatternSimilarities(db *gorm.DB, version uint) (map[string]map[string]float64, error) {
+	var rows []PatternSimilarity
+	var err error
+	if version == 0 {
+		err = db.Raw(patternCosines + `SELECT pattern_a, pattern_b, similarity FROM cosines`).Scan(&rows).Error
+	} else {
+		err = db.Where("version = ?", version).Find(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sims := map[string]map[string]float64{}
+	set := func(a, b string, s float64) {
+		if sims[a] == nil {
+			sims[a] = map[string]float64{}
+		}
+		sims[a][b] = s
+	}
+	for _, r := range rows {
+		set(r.PatternA, r.PatternB, r.Similarity)
+		set(r.PatternB, r.PatternA, r.Similarity)
+	}
+	return sims, nil
+}
+
+// loadPatternPopularity returns how many public submissions of version use
+// each pattern, relative to the most used one.
+func loadPatternPopularity(db *gorm.DB, version uint) (map[string]float64, error) {
+	var rows []PatternFrequency
+	if err := db.Where("version = ? AND language = ''", version).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	most := 0
+	for _, r := range rows {
+		if r.Submissions > most {
+			most = r.Submissions
+		}
+	}
+	popularity := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		popularity[r.Pattern] = float64(r.Submissions) / float64(most)
+	}
+	return popularity, nil
+}
+
+// SuggestPatterns ranks patterns userID has not used yet. neighbours are the
+// user's graph neighbours, strongest first, sims the pattern similarities
+// and popularity the relative use of each pattern, which stands in for
+// neighbour support when the user has no neighbours.
+func SuggestPatterns(profiles []UserPatternProfile, userID string, neighbours []Neighbour, sims map[string]map[string]float64, popularity map[string]float64, limit int) []PatternSuggestion {
+	byUser := make(map[string]map[string]int, len(profiles))
+	for _, p := range profiles {
+		byUser[p.UserID] = patternCounts(p)
+	}
+	own := byUser[userID]
+
+	// Neighbour support per candidate pattern.
+	support := map[string]float64{}
+	using := map[string]int{}
+	totalWeight := 0.0
+	for _, nb := range neighbours {
+		totalWeight += nb.Similarity
+		for name := range byUser[nb.UserID.String()] {
+			if _, known := own[name]; known {
+				continue
+			}
+			support[name] += nb.Similarity
+			using[name]++
+		}
+	}
+	if totalWeight == 0 {
+		for name, share := range popularity {
+			if _, known := own[name]; !known && share > 0 {
+				support[name] = share
+			}
+		}
+		totalWeight = 1
+	}
+
+	ownMass := 0.0
+	for _, c := range own {
+		ownMass += math.Log1p(float64(c))
+	}
+
+	out := []PatternSuggestion{}
+	for candidate, s := range support {
+		item := 0.0
+		related := map[string]float64{}
+		for name, c := range own {
+			contribution := sims[candidate][name] * math.Log1p(float64(c))
+			if contribution > 0 {
+				related[name] = contribution
+				item += contribution
+			}
+		}
+		if ownMass > 0 {
+			item /= ownMass
+		} else {
+			// A user without patterns gets pure popularity.
+			item = 1
+		}
+
+		out = append(out, PatternSuggestion{
+			Pattern:         candidate,
+			Score:           item * s / totalWeight,
+			NeighboursUsing: using[candidate],
+			Neighbours:      len(neighbours),
+			related:         related,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Pattern < out[j].Pattern
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+
+	for i := range out {
+		out[i].Because = topKeys(out[i].related, 3)
+	}
+	return out
+}
+
+// topKeys returns up to n keys with the largest values, largest first.
+func topKeys(m map[string]float64, n int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// patternExamples returns recent public submissions using pattern, taken
+// from the given users first and from anyone otherwise. Submissions of
+// excluded users are never returned.
+func patternExamples(db *gorm.DB, pattern string, users, excluded []uuid.UUID) ([]PatternExample, error) {
+	query := db.Table("code_submissions cs").
+		Select("cs.id AS submission_id, cs.user_id, u.username, cs.language").
+		Joins("JOIN submission_patterns sp ON sp.submission_id = cs.id").
+		Joins("JOIN algorithm_patterns ap ON ap.id = sp.pattern_id").
+		Joins("JOIN users u ON u.id = cs.user_id").
+		Where("ap.name = ? AND cs.visibility = 'public'", pattern)
+	if len(excluded) > 0 {
+		query = query.Where("cs.user_id NOT IN ?", excluded)
+	}
+
+	examples := []PatternExample{}
+	err := query.
+		Order(clause.Expr{SQL: "(cs.user_id IN ?) DESC, cs.created_at DESC", Vars: []interface{}{users}}).
+		Limit(cfExamples).
+		Scan(&examples).Error
+	return examples, err
+}
+
+// GetPatternRecommendations handles GET /api/recommendations/patterns.
+// Query parameter: limit (default 5, at most 20). Examples are never taken
+// from connected, pending or blocked users.
+func GetPatternRecommendations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+		if err != nil || limit < 1 || limit > maxPatternLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPatternLimit)})
+			return
+		}
+
+		adj, err := LoadAdjacency(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph"})
+			return
+		}
+		neighbours := adj.Neighbours(userID)
+		if len(neighbours) > cfNeighbours {
+			neighbours = neighbours[:cfNeighbours]
+		}
+
+		neighbourIDs := make([]uuid.UUID, len(neighbours))
+		members := []string{userID.String()}
+		for i, nb := range neighbours {
+			neighbourIDs[i] = nb.UserID
+			members = append(members, nb.UserID.String())
+		}
+
+		opts, err := ActiveBuildOptions(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph options"})
+			return
+		}
+		profiles, err := BuildProfilesFor(db, members, opts.Weights)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build profiles"})
+			return
+		}
+
+		excluded, err := connection.ExcludedUsers(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load connections"})
+			return
+		}
+
+		sims, err := loadPatternSimilarities(db, adj.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load pattern similarities"})
+			return
+		}
+		var popularity map[string]float64
+		if len(neighbours) == 0 {
+			if popularity, err = loadPatternPopularity(db, adj.Version); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load pattern frequencies"})
+				return
+			}
+		}
+
+		suggestions := SuggestPatterns(profiles, userID.String(), neighbours, sims, popularity, limit)
+
+		for i := range suggestions {
+			examples, err := patternExamples(db, suggestions[i].Pattern, neighbourIDs, excluded)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load examples"})
+				return
+			}
+			suggestions[i].Examples = examples
+		}
+
+		c.JSON(http.StatusOK, suggestions)
+	}
+}
@@ -0,0 +1,59 @@
+package graph
+
+import "testing"
+
+func TestSuggestPatterns(t *testing.T) {
+	profile := func(n int, patterns map[string]int) UserPatternProfile {
+		return UserPatternProfile{UserID: testUser(n).String(), Patterns: patterns}
+	}
+	profiles := []UserPatternProfile{
+		profile(1, map[string]int{"Loop": 3}),
+		profile(2, map[string]int{"Loop": 2, "Recursion": 4, "Hashing": 1}),
+		profile(3, map[string]int{"Sorting": 2}),
+	}
+	sims := map[string]map[string]float64{
+		"Loop":      {"Recursion": 0.8, "Hashing": 0.2, "Sorting": 0.5},
+		"Recursion": {"Loop": 0.8},
+		"Hashing":   {"Loop": 0.2},
+		"Sorting":   {"Loop": 0.5},
+	}
+	popularity := map[string]float64{"Loop": 1, "Sorting": 0.9, "Hashing": 0.6, "Recursion": 0.1}
+
+	tests := []struct {
+		name       string
+		user       int
+		neighbours []Neighbour
+		want       []string
+	}{
+		{
+			name:       "neighbour patterns ranked by similarity",
+			user:       1,
+			neighbours: []Neighbour{{testUser(2), 0.7}},
+			want:       []string{"Recursion", "Hashing"},
+		},
+		{
+			name: "no neighbours falls back to popularity",
+			user: 1,
+			want: []string{"Sorting", "Hashing", "Recursion"},
+		},
+		{
+			name: "no neighbours and no patterns",
+			user: 4,
+			want: []string{"Loop", "Sorting", "Hashing", "Recursion"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuggestPatterns(profiles, testUser(tt.user).String(), tt.neighbours, sims, popularity, 5)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d suggestions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, name := range tt.want {
+				if got[i].Pattern != name {
+					t.Errorf("suggestion %d = %s, want %s", i, got[i].Pattern, name)
+				}
+			}
+		})
+	}
+}
@@ -1,39 +1,243 @@
 package graph
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func PersistGraph(db *gorm.DB, edges []UserSimilarity) error {
+const (
+	// defaultRetention is how many inactive versions are kept for rollback.
+	defaultRetention = 3
+
+	persistBatchSize = 1000
+)
+
+// PersistGraph writes edges as a new graph version, recording the metric,
+// threshold and feature weights from opts and the corpus statistics the
+// edges were scored with, and atomically makes it the active one. Pairs are
+// normalised to (min, max) order and duplicates keep the highest score.
+// Communities, centrality scores, the pattern leaderboard rollup, the
+// pattern co-occurrence graph and the pattern similarities are stored with
+// the version before it is activated. The previous active version is
+// retired and old versions beyond the retention window are pruned
+// afterwards.
+func PersistGraph(db *gorm.DB, edges []UserSimilarity, opts BuildOptions, stats CorpusStats) (uint, error) {
+	return persistGraph(db, edges, opts, stats, nil)
+}
+
+// persistGraph is PersistGraph reporting written edges to progress. Once
+// progress is aborted it rolls back with ErrBuildLockLost instead of
+// activating the version.
+func persistGraph(db *gorm.DB, edges []UserSimilarity, opts BuildOptions, stats CorpusStats, progress *BuildProgress) (uint, error) {
+	type pair struct{ a, b uuid.UUID }
+	unique := map[pair]float64{}
+
 	for _, e := range edges {
-		userAID, err := uuid.Parse(e.UserA)
+		ua, ub := orderPair(e.UserA, e.UserB)
+
+		userAID, err := uuid.Parse(ua)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
-		userBID, err := uuid.Parse(e.UserB)
+		userBID, err := uuid.Parse(ub)
 		if err != nil {
+			return 0, err
+		}
+
+		key := pair{userAID, userBID}
+		if score, ok := unique[key]; !ok || e.Similarity > score {
+			unique[key] = e.Similarity
+		}
+	}
+
+	weights, err := json.Marshal(opts.Weights)
+	if err != nil {
+		return 0, err
+	}
+
+	version := GraphVersion{
+		Status:         VersionBuilding,
+		Metric:         opts.Metric,
+		Threshold:      opts.Threshold,
+		FeatureWeights: string(weights),
+		Profiles:       stats.Profiles,
+		AvgFeatureMass: stats.AvgMass,
+		EdgeCount:      len(unique),
+		CreatedAt:      time.Now(),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		rows := make([]UserSimilarityEdge, 0, len(unique))
+		for key, score := range unique {
+			rows = append(rows, UserSimilarityEdge{
+				ID:         uuid.New(),
+				Version:    version.ID,
+				UserA:      key.a,
+				UserB:      key.b,
+				Similarity: score,
+				Metric:     opts.Metric,
+				CreatedAt:  now,
+			})
+		}
+		for start := 0; start < len(rows); start += persistBatchSize {
+			end := start + persistBatchSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			if progress.aborted() {
+				return ErrBuildLockLost
+			}
+			if err := tx.Create(rows[start:end]).Error; err != nil {
+				return err
+			}
+			progress.addEdges(end - start)
+		}
+
+		if err := storeCorpusStats(tx, version.ID, stats); err != nil {
+			return err
+		}
+		if err := storeCommunities(tx, version.ID, rows); err != nil {
+			return err
+		}
+		if err := storeCentrality(tx, version.ID, rows); err != nil {
+			return err
+		}
+		if err := storePatternActivity(tx, version.ID); err != nil {
+			return err
+		}
+		if err := storePatternGraph(tx, version.ID); err != nil {
+			return err
+		}
+		if err := storePatternSimilarities(tx, version.ID); err != nil {
+			return err
+		}
+
+		if progress.aborted() {
+			return ErrBuildLockLost
+		}
+		return activate(tx, version.ID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := PruneVersions(db, retention()); err != nil {
+		log.Println("failed to prune graph versions:", err)
+	}
+	return version.ID, nil
+}
+
+// ActiveVersion returns the ID of the active graph version, or 0 when no
+// versioned build has been activated yet.
+func ActiveVersion(db *gorm.DB) (uint, error) {
+	var v GraphVersion
+	err := db.Where("status = ?", VersionActive).Order("id DESC").Take(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return v.ID, nil
+}
+
+// ActivateVersion rolls the graph back (or forward) to an existing version.
+func ActivateVersion(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var v GraphVersion
+		if err := tx.Where("id = ?", id).Take(&v).Error; err != nil {
 			return err
 		}
+		if v.Status == VersionBuilding {
+			return errors.New("version is still building")
+		}
+		return activate(tx, id)
+	})
+}
+
+// activate retires the current active version and activates id. It must run
+// inside a transaction so readers never observe zero or two active versions.
+func activate(tx *gorm.DB, id uint) error {
+	if err := tx.Model(&GraphVersion{}).
+		Where("status = ? AND id <> ?", VersionActive, id).
+		Update("status", VersionRetired).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Model(&GraphVersion{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       VersionActive,
+		"activated_at": now,
+	}).Error
+}
 
-		edge := UserSimilarityEdge{
-			ID:         uuid.New(),
-			UserA:      userAID,
-			UserB:      userBID,
-			Similarity: e.Similarity,
-			CreatedAt:  time.Now(),
+// versionedModels are the per-version tables deleted with their version.
+var versionedModels = []interface{}{
+	&UserSimilarityEdge{},
+	&UserCommunity{},
+	&Community{},
+	&UserCentrality{},
+	&PatternActivity{},
+	&PatternFrequency{},
+	&PatternCooccurrence{},
+	&PatternSimilarity{},
+	&FeatureFrequency{},
+}
+
+// PruneVersions deletes retired versions beyond the newest keep, together
+// with their edges, corpus statistics, communities, leaderboards and pattern
+// graphs, and drops unversioned legacy edges once a versioned build is
+// active.
+func PruneVersions(db *gorm.DB, keep int) error {
+	var retired []uint
+	if err := db.Model(&GraphVersion{}).
+		Where("status = ?", VersionRetired).
+		Order("id DESC").
+		Offset(keep).
+		Pluck("id", &retired).Error; err != nil {
+		return err
+	}
+
+	active, err := ActiveVersion(db)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if len(retired) > 0 {
+			for _, model := range versionedModels {
+				if err := tx.Where("version IN ?", retired).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("id IN ?", retired).Delete(&GraphVersion{}).Error; err != nil {
+				return err
+			}
+		}
+		if active > 0 {
+			return tx.Where("version = 0").Delete(&UserSimilarityEdge{}).Error
 		}
+		return nil
+	})
+}
 
-		db.FirstOrCreate(
-			&edge,
-			UserSimilarityEdge{
-				UserA: userAID,
-				UserB: userBID,
-			},
-		)
+// retention reads GRAPH_VERSION_RETENTION, falling back to defaultRetention.
+func retention() int {
+	if v, err := strconv.Atoi(os.Getenv("GRAPH_VERSION_RETENTION")); err == nil && v >= 0 {
+		return v
 	}
-	return nil
+	return defaultRetention
 }
@@ -1,6 +1,88 @@
 package graph
 
+import (
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Feature-key prefixes. Pattern features use the bare pattern name so that
+// profile keys match algorithm_patterns.name.
+const (
+	// ProblemFeaturePrefix marks profile keys that record a solved problem
+	// rather than a detected pattern.
+	ProblemFeaturePrefix    = "problem:"
+	ComplexityFeaturePrefix = "complexity:"
+	LanguageFeaturePrefix   = "lang:"
+)
+
+// UserPatternProfile describes one user for similarity purposes.
+//
+// Patterns holds raw, undecayed counts per pattern name and solved problem
+// and is what explanations are built from. Features is the full weighted
+// vector the metrics consume: time-decayed pattern and problem counts,
+// the time-complexity class distribution and the language mix, each group
+// scaled by its FeatureWeights entry.
 type UserPatternProfile struct {
 	UserID   string
 	Patterns map[string]int
+	Features map[string]float64
+}
+
+// Vector returns the profile's weighted feature vector. Profiles built
+// without Features (e.g. synthetic ones) fall back to raw pattern counts.
+func (p UserPatternProfile) Vector() map[string]float64 {
+	if p.Features != nil {
+		return p.Features
+	}
+	v := make(map[string]float64, len(p.Patterns))
+	for k, c := range p.Patterns {
+		v[k] = float64(c)
+	}
+	return v
+}
+
+// FeatureWeights scales each feature group of a profile vector. Every
+// submission contributes one unit to its language and complexity class and
+// one unit per detected pattern, multiplied by the group weight and by
+// 2^(-age/HalfLife) so recent work counts more. A zero weight drops the
+// group; a zero HalfLife disables decay.
+type FeatureWeights struct {
+	Patterns     float64 `json:"patterns"`
+	Problems     float64 `json:"problems"`
+	Complexity   float64 `json:"complexity"`
+	Language     float64 `json:"language"`
+	HalfLifeDays float64 `json:"half_life_days"`
+}
+
+// DefaultFeatureWeights reads GRAPH_WEIGHT_PATTERNS, GRAPH_WEIGHT_PROBLEMS,
+// GRAPH_WEIGHT_COMPLEXITY, GRAPH_WEIGHT_LANGUAGE and GRAPH_HALF_LIFE_DAYS.
+func DefaultFeatureWeights() FeatureWeights {
+	return FeatureWeights{
+		Patterns:     envFloat("GRAPH_WEIGHT_PATTERNS", 1),
+		Problems:     envFloat("GRAPH_WEIGHT_PROBLEMS", 1),
+		Complexity:   envFloat("GRAPH_WEIGHT_COMPLEXITY", 0.5),
+		Language:     envFloat("GRAPH_WEIGHT_LANGUAGE", 0.25),
+		HalfLifeDays: envFloat("GRAPH_HALF_LIFE_DAYS", 180),
+	}
+}
+
+// decay returns the recency multiplier for work created at t.
+func (w FeatureWeights) decay(t, now time.Time) float64 {
+	if w.HalfLifeDays <= 0 || t.IsZero() {
+		return 1
+	}
+	age := now.Sub(t).Hours() / 24
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp2(-age / w.HalfLifeDays)
+}
+
+func envFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && v >= 0 {
+		return v
+	}
+	return fallback
 }
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Build phases reported by BuildProgress.
+const (
+	PhaseProfiles = "profiles"
+	PhaseScoring  = "scoring"
+	PhasePersist  = "persisting"
+)
+
+// BuildProgress counts the work done by a running graph build and carries
+// its abort signal. It is safe for concurrent use, and a nil *BuildProgress
+// ignores all updates and is never aborted so the build pipeline can run
+// without tracking.
+type BuildProgress struct {
+	profiles int64
+	pairs    int64
+	edges    int64
+	stopped  int32
+
+	mu    sync.Mutex
+	phase string
+}
+
+// ProgressSnapshot is a consistent-enough copy of the counters for
+// reporting.
+type ProgressSnapshot struct {
+	Phase          string `json:"phase"`
+	ProfilesBuilt  int64  `json:"profiles_built"`
+	PairsEvaluated int64  `json:"pairs_evaluated"`
+	EdgesWritten   int64  `json:"edges_written"`
+}
+
+func (p *BuildProgress) setPhase(phase string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.phase = phase
+	p.mu.Unlock()
+}
+
+func (p *BuildProgress) addProfiles(n int) {
+	if p != nil {
+		atomic.AddInt64(&p.profiles, int64(n))
+	}
+}
+
+func (p *BuildProgress) addPairs(n int) {
+	if p != nil {
+		atomic.AddInt64(&p.pairs, int64(n))
+	}
+}
+
+func (p *BuildProgress) addEdges(n int) {
+	if p != nil {
+		atomic.AddInt64(&p.edges, int64(n))
+	}
+}
+
+// abort asks the build to stop at its next check.
+func (p *BuildProgress) abort() {
+	if p != nil {
+		atomic.StoreInt32(&p.stopped, 1)
+	}
+}
+
+// aborted reports whether abort was called.
+func (p *BuildProgress) aborted() bool {
+	return p != nil && atomic.LoadInt32(&p.stopped) == 1
+}
+
+func (p *BuildProgress) Snapshot() ProgressSnapshot {
+	if p == nil {
+		return ProgressSnapshot{}
+	}
+	p.mu.Lock()
+	phase := p.phase
+	p.mu.Unlock()
+	return ProgressSnapshot{
+		Phase:          phase,
+		ProfilesBuilt:  atomic.LoadInt64(&p.profiles),
+		PairsEvaluated: atomic.LoadInt64(&p.pairs),
+		EdgesWritten:   atomic.LoadInt64(&p.edges),
+	}
+}
@@ -0,0 +1,28 @@
+package graph
+
+import "testing"
+
+func TestBuildSimilarityGraphAborted(t *testing.T) {
+	for _, n := range []int{50, lshMinProfiles} {
+		profiles := syntheticProfiles(n, 1)
+		metric, stats, _ := fittedVectors(t, MetricCosineTFIDF, profiles)
+
+		if edges := buildSimilarityGraph(profiles, metric, stats, DefaultThreshold, &BuildProgress{}); len(edges) == 0 {
+			t.Fatalf("%d profiles: no edges without abort", n)
+		}
+
+		progress := &BuildProgress{}
+		progress.abort()
+		if edges := buildSimilarityGraph(profiles, metric, stats, DefaultThreshold, progress); len(edges) != 0 {
+			t.Errorf("%d profiles: %d edges after abort, want 0", n, len(edges))
+		}
+	}
+}
+
+func TestNilBuildProgressNeverAborted(t *testing.T) {
+	var progress *BuildProgress
+	progress.abort()
+	if progress.aborted() {
+		t.Error("nil progress reports aborted")
+	}
+}
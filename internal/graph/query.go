@@ -0,0 +1,188 @@
+package graph
+
+// query.go — multi-hop queries over an Adjacency snapshot
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// MaxQueryHops bounds k-hop and ego-network queries.
+const MaxQueryHops = 4
+
+const (
+	// PathWidest maximises the weakest edge on the path.
+	PathWidest = "widest"
+	// PathProduct maximises the product of edge similarities.
+	PathProduct = "product"
+)
+
+type HopNode struct {
+	UserID uuid.UUID `json:"user_id"`
+	Hops   int       `json:"hops"`
+}
+
+type SubgraphEdge struct {
+	Source     uuid.UUID `json:"source"`
+	Target     uuid.UUID `json:"target"`
+	Similarity float64   `json:"similarity"`
+}
+
+type Subgraph struct {
+	Center uuid.UUID      `json:"center"`
+	Nodes  []HopNode      `json:"nodes"`
+	Edges  []SubgraphEdge `json:"edges"`
+}
+
+type Path struct {
+	Mode         string      `json:"mode"`
+	Users        []uuid.UUID `json:"users"`
+	Similarities []float64   `json:"similarities"`
+	Score        float64     `json:"score"`
+}
+
+// KHop returns every user within k hops of center over edges of at least
+// minSimilarity, in breadth-first order. The center itself is included with
+// Hops 0.
+func (a *Adjacency) KHop(center uuid.UUID, k int, minSimilarity float64) []HopNode {
+	hops := map[uuid.UUID]int{center: 0}
+	out := []HopNode{{UserID: center, Hops: 0}}
+	frontier := []uuid.UUID{center}
+
+	for depth := 1; depth <= k && len(frontier) > 0; depth++ {
+		next := []uuid.UUID{}
+		for _, u := range frontier {
+			for _, nb := range a.neighbours[u] {
+				// Lists are sorted strongest first, so the rest are weaker.
+				if nb.Similarity < minSimilarity {
+					break
+				}
+				if _, seen := hops[nb.UserID]; seen {
+					continue
+				}
+				hops[nb.UserID] = depth
+				out = append(out, HopNode{UserID: nb.UserID, Hops: depth})
+				next = append(next, nb.UserID)
+			}
+		}
+		frontier = next
+	}
+	return out
+}
+
+// EgoNetwork returns the subgraph induced by the radius-hop neighbourhood of
+// center: its nodes and every edge of at least minSimilarity between them.
+func (a *Adjacency) EgoNetwork(center uuid.UUID, radius int, minSimilarity float64) Subgraph {
+	nodes := a.KHop(center, radius, minSimilarity)
+	in := make(map[uuid.UUID]bool, len(nodes))
+	for _, n := range nodes {
+		in[n.UserID] = true
+	}
+
+	edges := []SubgraphEdge{}
+	for _, n := range nodes {
+		for _, nb := range a.neighbours[n.UserID] {
+			if nb.Similarity < minSimilarity {
+				break
+			}
+			// Emit each undirected edge once.
+			if in[nb.UserID] && n.UserID.String() < nb.UserID.String() {
+				edges = append(edges, SubgraphEdge{Source: n.UserID, Target: nb.UserID, Similarity: nb.Similarity})
+			}
+		}
+	}
+	return Subgraph{Center: center, Nodes: nodes, Edges: edges}
+}
+
+// StrongestPath finds the path from one user to another that maximises
+// either the weakest edge (PathWidest) or the product of similarities
+// (PathProduct), using only edges of at least minSimilarity. Both scores
+// can only shrink along a path because similarities are at most 1, so a
+// best-first search (Dijkstra with max instead of min) is exact. ok is
+// false when no path exists.
+func (a *Adjacency) StrongestPath(from, to uuid.UUID, mode string, minSimilarity float64) (Path, bool) {
+	combine := math.Min
+	if mode == PathProduct {
+		combine = func(score, w float64) float64 { return score * w }
+	} else {
+		mode = PathWidest
+	}
+
+	if from == to {
+		return Path{Mode: mode, Users: []uuid.UUID{from}, Similarities: []float64{}, Score: 1}, true
+	}
+
+	best := map[uuid.UUID]float64{from: 1}
+	prev := map[uuid.UUID]Neighbour{}
+	done := map[uuid.UUID]bool{}
+
+	pq := &pathQueue{{user: from, score: 1}}
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(pathItem)
+		if done[cur.user] {
+			continue
+		}
+		done[cur.user] = true
+		if cur.user == to {
+			break
+		}
+
+		for _, nb := range a.neighbours[cur.user] {
+			if nb.Similarity < minSimilarity {
+				break
+			}
+			if done[nb.UserID] {
+				continue
+			}
+			score := combine(cur.score, nb.Similarity)
+			if old, ok := best[nb.UserID]; ok && old >= score {
+				continue
+			}
+			best[nb.UserID] = score
+			prev[nb.UserID] = Neighbour{UserID: cur.user, Similarity: nb.Similarity}
+			heap.Push(pq, pathItem{user: nb.UserID, score: score})
+		}
+	}
+
+	if !done[to] {
+		return Path{}, false
+	}
+
+	users := []uuid.UUID{to}
+	sims := []float64{}
+	for u := to; u != from; {
+		p := prev[u]
+		users = append(users, p.UserID)
+		sims = append(sims, p.Similarity)
+		u = p.UserID
+	}
+	for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+		users[i], users[j] = users[j], users[i]
+	}
+	for i, j := 0, len(sims)-1; i < j; i, j = i+1, j-1 {
+		sims[i], sims[j] = sims[j], sims[i]
+	}
+
+	return Path{Mode: mode, Users: users, Similarities: sims, Score: best[to]}, true
+}
+
+type pathItem struct {
+	user  uuid.UUID
+	score float64
+}
+
+// pathQueue is a max-heap of pathItems by score.
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].score > q[j].score }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
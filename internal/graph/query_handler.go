@@ -0,0 +1,200 @@
+package graph
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type QueryNode struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Hops     int       `json:"hops"`
+}
+
+// GetNeighbourhood handles GET /api/graph/neighbourhood.
+// Query parameters: user (default: caller), k (default 2) and
+// min_similarity.
+func GetNeighbourhood(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		center, hops, minSimilarity, ok := parseHopQuery(c, "k", 2)
+		if !ok {
+			return
+		}
+
+		adj, err := LoadAdjacency(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph"})
+			return
+		}
+
+		nodes := adj.KHop(center, hops, minSimilarity)
+		c.JSON(http.StatusOK, gin.H{
+			"center":  center,
+			"version": adj.Version,
+			"nodes":   withUsernames(db, nodes),
+		})
+	}
+}
+
+// GetEgoNetwork handles GET /api/graph/ego.
+// Query parameters: user (default: caller), radius (default 1) and
+// min_similarity.
+func GetEgoNetwork(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		center, radius, minSimilarity, ok := parseHopQuery(c, "radius", 1)
+		if !ok {
+			return
+		}
+
+		adj, err := LoadAdjacency(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph"})
+			return
+		}
+
+		ego := adj.EgoNetwork(center, radius, minSimilarity)
+		c.JSON(http.StatusOK, gin.H{
+			"center":  center,
+			"version": adj.Version,
+			"nodes":   withUsernames(db, ego.Nodes),
+			"edges":   ego.Edges,
+		})
+	}
+}
+
+// GetStrongestPath handles GET /api/graph/path.
+// Query parameters: from (default: caller), to, mode (widest or product;
+// default widest) and min_similarity.
+func GetStrongestPath(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from := c.MustGet("user_id").(uuid.UUID)
+		if v := c.Query("from"); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from user id"})
+				return
+			}
+			from = id
+		}
+
+		to, err := uuid.Parse(c.Query("to"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to user id"})
+			return
+		}
+
+		mode := c.DefaultQuery("mode", PathWidest)
+		if mode != PathWidest && mode != PathProduct {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be widest or product"})
+			return
+		}
+
+		minSimilarity, ok := parseMinSimilarity(c)
+		if !ok {
+			return
+		}
+
+		adj, err := LoadAdjacency(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph"})
+			return
+		}
+
+		path, found := adj.StrongestPath(from, to, mode, minSimilarity)
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no path between users"})
+			return
+		}
+
+		names := usernames(db, path.Users)
+		hops := make([]QueryNode, len(path.Users))
+		for i, u := range path.Users {
+			hops[i] = QueryNode{UserID: u, Username: names[u], Hops: i}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"version":      adj.Version,
+			"mode":         path.Mode,
+			"score":        path.Score,
+			"users":        hops,
+			"similarities": path.Similarities,
+		})
+	}
+}
+
+// parseHopQuery reads the user, hop-count and min_similarity parameters
+// shared by the neighbourhood and ego queries. It writes the error response
+// itself and returns ok == false on invalid input.
+func parseHopQuery(c *gin.Context, hopParam string, defaultHops int) (uuid.UUID, int, float64, bool) {
+	center := c.MustGet("user_id").(uuid.UUID)
+	if v := c.Query("user"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return uuid.Nil, 0, 0, false
+		}
+		center = id
+	}
+
+	hops, err := strconv.Atoi(c.DefaultQuery(hopParam, strconv.Itoa(defaultHops)))
+	if err != nil || hops < 0 || hops > MaxQueryHops {
+		c.JSON(http.StatusBadRequest, gin.H{"error": hopParam + " must be between 0 and " + strconv.Itoa(MaxQueryHops)})
+		return uuid.Nil, 0, 0, false
+	}
+
+	minSimilarity, ok := parseMinSimilarity(c)
+	if !ok {
+		return uuid.Nil, 0, 0, false
+	}
+	return center, hops, minSimilarity, true
+}
+
+func parseMinSimilarity(c *gin.Context) (float64, bool) {
+	v := c.Query("min_similarity")
+	if v == "" {
+		return 0, true
+	}
+	min, err := strconv.ParseFloat(v, 64)
+	if err != nil || min < 0 || min > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_similarity must be in [0, 1]"})
+		return 0, false
+	}
+	return min, true
+}
+
+func withUsernames(db *gorm.DB, nodes []HopNode) []QueryNode {
+	ids := make([]uuid.UUID, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.UserID
+	}
+	names := usernames(db, ids)
+
+	out := make([]QueryNode, len(nodes))
+	for i, n := range nodes {
+		out[i] = QueryNode{UserID: n.UserID, Username: names[n.UserID], Hops: n.Hops}
+	}
+	return out
+}
+
+// usernames resolves user IDs to usernames in one query. Unknown IDs are
+// missing from the result.
+func usernames(db *gorm.DB, ids []uuid.UUID) map[uuid.UUID]string {
+	names := map[uuid.UUID]string{}
+	if len(ids) == 0 {
+		return names
+	}
+
+	var rows []struct {
+		ID       uuid.UUID
+		Username string
+	}
+	db.Raw("SELECT id, username FROM users WHERE id IN ?", ids).Scan(&rows)
+	for _, r := range rows {
+		names[r.ID] = r.Username
+	}
+	return names
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// queryGraph is the chain 1-2-3-4 (0.9, 0.8, 0.7), a detour 1-5-4 (0.2,
+// 0.95) and a weak spur 2-6 (0.05).
+func queryGraph() *Adjacency {
+	return testAdjacency(testEdges(
+		[3]float64{1, 2, 0.9}, [3]float64{2, 3, 0.8}, [3]float64{3, 4, 0.7},
+		[3]float64{1, 5, 0.2}, [3]float64{5, 4, 0.95}, [3]float64{2, 6, 0.05},
+	))
+}
+
+func TestKHop(t *testing.T) {
+	adj := queryGraph()
+
+	tests := []struct {
+		name          string
+		center        int
+		k             int
+		minSimilarity float64
+		want          [][2]int // testUser number, hops
+	}{
+		{"zero hops", 1, 0, 0, [][2]int{{1, 0}}},
+		{"one hop, strongest first", 1, 1, 0, [][2]int{{1, 0}, {2, 1}, {5, 1}}},
+		{"two hops", 1, 2, 0, [][2]int{{1, 0}, {2, 1}, {5, 1}, {3, 2}, {6, 2}, {4, 2}}},
+		{"weak edges pruned", 1, 2, 0.5, [][2]int{{1, 0}, {2, 1}, {3, 2}}},
+		{"shortest hop count wins", 4, 4, 0, [][2]int{{4, 0}, {5, 1}, {3, 1}, {1, 2}, {2, 2}, {6, 3}}},
+		{"unknown user", 9, 2, 0, [][2]int{{9, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make([]HopNode, len(tt.want))
+			for i, w := range tt.want {
+				want[i] = HopNode{UserID: testUser(w[0]), Hops: w[1]}
+			}
+			if got := adj.KHop(testUser(tt.center), tt.k, tt.minSimilarity); !reflect.DeepEqual(got, want) {
+				t.Errorf("KHop = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEgoNetwork(t *testing.T) {
+	adj := queryGraph()
+
+	got := adj.EgoNetwork(testUser(1), 1, 0)
+	want := Subgraph{
+		Center: testUser(1),
+		Nodes:  []HopNode{{testUser(1), 0}, {testUser(2), 1}, {testUser(5), 1}},
+		Edges: []SubgraphEdge{
+			{Source: testUser(1), Target: testUser(2), Similarity: 0.9},
+			{Source: testUser(1), Target: testUser(5), Similarity: 0.2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EgoNetwork = %+v, want %+v", got, want)
+	}
+
+	// Edges between two members are included even when neither is the
+	// center; edges to non-members are not.
+	got = adj.EgoNetwork(testUser(4), 1, 0)
+	if len(got.Edges) != 2 {
+		t.Errorf("ego of 4 has %d edges, want 2 (4-5, 3-4): %+v", len(got.Edges), got.Edges)
+	}
+	got = adj.EgoNetwork(testUser(2), 2, 0)
+	for _, e := range got.Edges {
+		if e.Source.String() >= e.Target.String() {
+			t.Errorf("edge %v-%v emitted out of order", e.Source, e.Target)
+		}
+	}
+	if len(got.Edges) != 6 {
+		t.Errorf("ego of 2 at radius 2 has %d edges, want all 6", len(got.Edges))
+	}
+}
+
+func TestStrongestPath(t *testing.T) {
+	// A direct 1-4 edge of 0.6 beats the 1-2-3-4 chain on the product
+	// (0.504) but not on the weakest link (0.7).
+	adj := testAdjacency(testEdges(
+		[3]float64{1, 2, 0.9}, [3]float64{2, 3, 0.8}, [3]float64{3, 4, 0.7},
+		[3]float64{1, 4, 0.6}, [3]float64{1, 5, 0.2}, [3]float64{5, 4, 0.95},
+	))
+
+	tests := []struct {
+		name          string
+		from, to      int
+		mode          string
+		minSimilarity float64
+		users         []int
+		score         float64
+		ok            bool
+	}{
+		{"widest", 1, 4, PathWidest, 0, []int{1, 2, 3, 4}, 0.7, true},
+		{"product", 1, 4, PathProduct, 0, []int{1, 4}, 0.6, true},
+		{"unknown mode is widest", 1, 4, "shortest", 0, []int{1, 2, 3, 4}, 0.7, true},
+		{"min similarity blocks every route", 1, 4, PathWidest, 0.75, nil, 0, false},
+		{"min similarity reroutes", 1, 4, PathProduct, 0.65, []int{1, 2, 3, 4}, 0.504, true},
+		{"same user", 3, 3, PathWidest, 0, []int{3}, 1, true},
+		{"unreachable", 1, 9, PathWidest, 0, nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := adj.StrongestPath(testUser(tt.from), testUser(tt.to), tt.mode, tt.minSimilarity)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+
+			users := make([]uuid.UUID, len(tt.users))
+			for i, n := range tt.users {
+				users[i] = testUser(n)
+			}
+			if !reflect.DeepEqual(path.Users, users) {
+				t.Errorf("users = %v, want %v", path.Users, users)
+			}
+			if len(path.Similarities) != len(users)-1 {
+				t.Errorf("%d similarities for %d users", len(path.Similarities), len(users))
+			}
+			if math.Abs(path.Score-tt.score) > 1e-9 {
+				t.Errorf("score = %v, want %v", path.Score, tt.score)
+			}
+		})
+	}
+}
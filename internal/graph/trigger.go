@@ -2,54 +2,71 @@ package graph
 
 import (
 	"log"
-	"net/http"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// Trigger graph building manually
-func BuildGraph(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		err := RebuildSimilarityGraph(db)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build graph"})
-			return
-		}
+// rebuild builds profiles, scores pairs and persists the result as a new
+// active graph version using the given metric, threshold and feature
+// weights. It returns the new version, or 0 when there were too few users
+// to build a graph. Callers must hold the build lock; the build stops with
+// ErrBuildLockLost once progress is aborted.
+func rebuild(db *gorm.DB, opts BuildOptions, progress *BuildProgress) (uint, error) {
+	log.Printf("Building similarity graph (metric=%s, threshold=%.2f)...\n", opts.Metric, opts.Threshold)
 
-		c.JSON(http.StatusOK, gin.H{"message": "graph built successfully"})
+	metric, err := opts.metric()
+	if err != nil {
+		return 0, err
 	}
-}
 
-// Background function to rebuild the similarity graph
-func RebuildSimilarityGraph(db *gorm.DB) error {
-	log.Println("Building similarity graph...")
+	// Incremental updates from here on are not seen by this build and are
+	// replayed once it is active.
+	started := time.Now()
 
 	// Build user profiles
-	profiles, err := BuildUserProfiles(db)
+	progress.setPhase(PhaseProfiles)
+	profiles, err := BuildUserProfilesWith(db, opts.Weights)
 	if err != nil {
 		log.Println("failed to build profiles:", err)
-		return err
+		return 0, err
+	}
+	progress.addProfiles(len(profiles))
+	if progress.aborted() {
+		return 0, ErrBuildLockLost
 	}
 
 	log.Printf("Built %d user profiles\n", len(profiles))
 
 	if len(profiles) < 2 {
 		log.Println("Need at least 2 users to build graph")
-		return nil
+		return 0, nil
 	}
 
-	// Build similarity graph with threshold 0.1 (10% similarity)
-	edges := BuildSimilarityGraph(profiles, 0.1)
+	progress.setPhase(PhaseScoring)
+	stats := NewCorpusStats(profiles)
+	edges := buildSimilarityGraph(profiles, metric, stats, opts.Threshold, progress)
+	if progress.aborted() {
+		return 0, ErrBuildLockLost
+	}
 	log.Printf("Found %d similarity edges\n", len(edges))
 
-	// Persist to database
-	err = PersistGraph(db, edges)
+	// Persist to database as a new version
+	progress.setPhase(PhasePersist)
+	version, err := persistGraph(db, edges, opts, stats, progress)
 	if err != nil {
 		log.Println("failed to persist graph:", err)
-		return err
+		return 0, err
+	}
+
+	if err := replayUserUpdates(db, started); err != nil {
+		log.Println("failed to replay incremental graph updates:", err)
+	}
+
+	if err := RefreshAdjacency(db); err != nil {
+		log.Println("failed to refresh adjacency cache:", err)
 	}
 
-	log.Println("Similarity graph built successfully")
-	return nil
+	log.Printf("Similarity graph version %d built successfully\n", version)
+	return version, nil
 }
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type VersionResponse struct {
+	ID          uint   `json:"id"`
+	Status      string `json:"status"`
+	EdgeCount   int    `json:"edge_count"`
+	CreatedAt   string `json:"created_at"`
+	ActivatedAt string `json:"activated_at,omitempty"`
+}
+
+// ListVersions handles GET /api/graph/versions.
+func ListVersions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var versions []GraphVersion
+		if err := db.Order("id DESC").Find(&versions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load graph versions"})
+			return
+		}
+
+		out := make([]VersionResponse, 0, len(versions))
+		for _, v := range versions {
+			resp := VersionResponse{
+				ID:        v.ID,
+				Status:    v.Status,
+				EdgeCount: v.EdgeCount,
+				CreatedAt: v.CreatedAt.Format("2006-01-02 15:04:05"),
+			}
+			if v.ActivatedAt != nil {
+				resp.ActivatedAt = v.ActivatedAt.Format("2006-01-02 15:04:05")
+			}
+			out = append(out, resp)
+		}
+
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// ActivateGraphVersion handles POST /api/graph/versions/:id/activate.
+// Used to roll back to a previous build.
+func ActivateGraphVersion(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version id"})
+			return
+		}
+
+		if err := ActivateVersion(db, uint(id)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to activate version"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "graph version activated", "version": id})
+	}
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewFromEnv builds the mailer selected by MAIL_DRIVER: smtp, file (writes
+// messages to MAIL_DIR) or log. The log driver writes live account tokens
+// to the server log, so it is only used when chosen explicitly; an empty or
+// unknown driver is an error.
+func NewFromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "DevGraph <no-reply@devgraph.local>"
+	}
+
+	switch strings.ToLower(os.Getenv("MAIL_DRIVER")) {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return &FileMailer{Dir: dir, From: from}, nil
+	case "log":
+		log.Println("MAIL_DRIVER=log: emails, including account tokens, are written to the log")
+		return &LogMailer{Logger: log.Default()}, nil
+	case "":
+		return nil, fmt.Errorf("MAIL_DRIVER is not set (smtp, file or log)")
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q (smtp, file or log)", os.Getenv("MAIL_DRIVER"))
+	}
+}
+
+// format renders msg as an RFC 5322 message.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,245 @@
+package plagiarism
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// Threshold is the minimum coverage (on either side of a pair) for two
+	// submissions to be reported as near-duplicates.
+	Threshold = 0.5
+
+	// minFingerprints skips tiny snippets whose fingerprints are dominated
+	// by boilerplate and would match almost anything.
+	minFingerprints = 5
+
+	// maxCandidates bounds how many other submissions are compared in
+	// detail for a single check.
+	maxCandidates = 20
+)
+
+// CheckSubmission fingerprints a submission, stores its fingerprints and
+// records a SubmissionMatch for every submission by another user whose
+// similarity is at or above Threshold. It is called by the analysis worker
+// once a submission's analysis has been stored and is safe to re-run: old
+// fingerprints and matches for the submission are replaced.
+//
+// Only public submissions are compared. A match reveals the other side's
+// submission, owner and line regions, so private code is never matched in
+// either direction.
+func CheckSubmission(db *gorm.DB, submissionID uuid.UUID) error {
+	var submission struct {
+		ID         uuid.UUID
+		UserID     uuid.UUID
+		SourceCode string
+		Visibility string
+	}
+
+	if err := db.Table("code_submissions").
+		Select("id, user_id, source_code, visibility").
+		Where("id = ?", submissionID).
+		Scan(&submission).Error; err != nil {
+		return err
+	}
+
+	var prints []fingerprint
+	if submission.Visibility == "public" {
+		prints = fingerprintSource(submission.SourceCode)
+	}
+
+	if err := storeFingerprints(db, submission.ID, prints); err != nil {
+		return err
+	}
+
+	if len(prints) < minFingerprints {
+		return nil
+	}
+
+	candidates, err := findCandidates(db, submission.ID, submission.UserID, prints)
+	if err != nil {
+		return err
+	}
+
+	for _, cand := range candidates {
+		other, err := loadFingerprints(db, cand.SubmissionID)
+		if err != nil {
+			return err
+		}
+		if len(other) < minFingerprints {
+			continue
+		}
+
+		shared, regions := compareFingerprints(prints, other)
+		coverageA := float64(shared) / float64(len(uniqueHashes(prints)))
+		coverageB := float64(shared) / float64(len(uniqueHashes(other)))
+
+		if coverageA < Threshold && coverageB < Threshold {
+			continue
+		}
+
+		encoded, err := json.Marshal(regions)
+		if err != nil {
+			return err
+		}
+
+		match := SubmissionMatch{
+			ID:          uuid.New(),
+			SubmissionA: submission.ID,
+			SubmissionB: cand.SubmissionID,
+			UserA:       submission.UserID,
+			UserB:       cand.UserID,
+			SharedCount: shared,
+			CoverageA:   coverageA,
+			CoverageB:   coverageB,
+			Regions:     string(encoded),
+			CreatedAt:   time.Now(),
+		}
+		if err := db.Create(&match).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Printf("plagiarism check done for submission %s (%d candidates)\n", submission.ID, len(candidates))
+	return nil
+}
+
+// storeFingerprints replaces the stored fingerprints of a submission and
+// drops any matches previously recorded for it.
+func storeFingerprints(db *gorm.DB, submissionID uuid.UUID, prints []fingerprint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("submission_id = ?", submissionID).
+			Delete(&SubmissionFingerprint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("submission_a = ? OR submission_b = ?", submissionID, submissionID).
+			Delete(&SubmissionMatch{}).Error; err != nil {
+			return err
+		}
+		if len(prints) == 0 {
+			return nil
+		}
+
+		rows := make([]SubmissionFingerprint, len(prints))
+		for i, p := range prints {
+			rows[i] = SubmissionFingerprint{
+				ID:           uuid.New(),
+				SubmissionID: submissionID,
+				Hash:         p.hash,
+				StartLine:    p.startLine,
+				EndLine:      p.endLine,
+			}
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+type candidate struct {
+	SubmissionID uuid.UUID
+	UserID       uuid.UUID
+	Shared       int
+}
+
+// findCandidates returns public submissions by other users that share at
+// least one fingerprint hash, ordered by the number of shared hashes.
+func findCandidates(db *gorm.DB, submissionID, userID uuid.UUID, prints []fingerprint) ([]candidate, error) {
+	hashes := make([]int64, 0, len(prints))
+	for h := range uniqueHashes(prints) {
+		hashes = append(hashes, h)
+	}
+
+	var candidates []candidate
+	err := db.Raw(`
+		SELECT sf.submission_id, cs.user_id, COUNT(DISTINCT sf.hash) AS shared
+		FROM submission_fingerprints sf
+		JOIN code_submissions cs ON cs.id = sf.submission_id
+		WHERE sf.hash IN ?
+		  AND sf.submission_id <> ?
+		  AND cs.user_id <> ?
+		  AND cs.visibility = 'public'
+		GROUP BY sf.submission_id, cs.user_id
+		ORDER BY shared DESC
+		LIMIT ?
+	`, hashes, submissionID, userID, maxCandidates).Scan(&candidates).Error
+
+	return candidates, err
+}
+
+func loadFingerprints(db *gorm.DB, submissionID uuid.UUID) ([]fingerprint, error) {
+	var rows []SubmissionFingerprint
+	if err := db.Where("submission_id = ?", submissionID).
+		Order("start_line").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	prints := make([]fingerprint, len(rows))
+	for i, r := range rows {
+		prints[i] = fingerprint{hash: r.Hash, startLine: r.StartLine, endLine: r.EndLine}
+	}
+	return prints, nil
+}
+
+func uniqueHashes(prints []fingerprint) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(prints))
+	for _, p := range prints {
+		set[p.hash] = struct{}{}
+	}
+	return set
+}
+
+// compareFingerprints counts the distinct hashes shared by a and b and
+// aligns the matching fingerprints into line-range regions. Pairs are walked
+// in A's line order and merged while both sides keep moving forward.
+func compareFingerprints(a, b []fingerprint) (int, []MatchRegion) {
+	byHash := map[int64][]fingerprint{}
+	for _, p := range b {
+		byHash[p.hash] = append(byHash[p.hash], p)
+	}
+
+	type pair struct{ a, b fingerprint }
+	pairs := []pair{}
+	shared := map[int64]struct{}{}
+	for _, p := range a {
+		for _, q := range byHash[p.hash] {
+			pairs = append(pairs, pair{p, q})
+			shared[p.hash] = struct{}{}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a.startLine != pairs[j].a.startLine {
+			return pairs[i].a.startLine < pairs[j].a.startLine
+		}
+		return pairs[i].b.startLine < pairs[j].b.startLine
+	})
+
+	regions := []MatchRegion{}
+	for _, p := range pairs {
+		if n := len(regions); n > 0 {
+			r := &regions[n-1]
+			if p.a.startLine <= r.AEnd+1 && p.b.startLine >= r.BStart && p.b.startLine <= r.BEnd+1 {
+				if p.a.endLine > r.AEnd {
+					r.AEnd = p.a.endLine
+				}
+				if p.b.endLine > r.BEnd {
+					r.BEnd = p.b.endLine
+				}
+				continue
+			}
+		}
+		regions = append(regions, MatchRegion{
+			AStart: p.a.startLine,
+			AEnd:   p.a.endLine,
+			BStart: p.b.startLine,
+			BEnd:   p.b.endLine,
+		})
+	}
+
+	return len(shared), regions
+}
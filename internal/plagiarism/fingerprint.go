@@ -0,0 +1,195 @@
+package plagiarism
+
+// fingerprint.go — token normalisation and winnowing
+//
+// The pipeline follows the MOSS approach (Schleimer, Wilkerson & Aiken,
+// "Winnowing: Local Algorithms for Document Fingerprinting", 2003):
+//
+//  1. tokenize strips comments and whitespace and normalises the remaining
+//     lexemes: identifiers become "I", numeric literals "N" and string/char
+//     literals "S". Keywords and punctuation are kept verbatim, so the
+//     structure of the program survives but renaming does not matter.
+//  2. Every run of kgramSize consecutive tokens is hashed.
+//  3. winnow slides a window of windowSize hashes over the sequence and keeps
+//     the minimum of each window. Any shared run of at least
+//     kgramSize+windowSize-1 tokens is guaranteed to produce a shared
+//     fingerprint, while the number of stored hashes stays small.
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+const (
+	kgramSize  = 10
+	windowSize = 5
+)
+
+type token struct {
+	text string
+	line int
+}
+
+type fingerprint struct {
+	hash      int64
+	startLine int
+	endLine   int
+}
+
+// keywords are kept verbatim by tokenize. The set is the union of the
+// control-flow and declaration keywords of the supported languages; type
+// names are deliberately excluded so that int/long swaps normalise away.
+var keywords = map[string]struct{}{
+	"if": {}, "else": {}, "for": {}, "while": {}, "do": {}, "switch": {},
+	"case": {}, "default": {}, "return": {}, "break": {}, "continue": {},
+	"class": {}, "struct": {}, "new": {}, "delete": {}, "try": {},
+	"catch": {}, "throw": {}, "func": {}, "def": {}, "function": {},
+	"range": {}, "in": {}, "elif": {}, "lambda": {}, "yield": {},
+	"go": {}, "defer": {}, "select": {}, "import": {}, "package": {},
+	"true": {}, "false": {}, "null": {}, "nil": {}, "None": {},
+	"True": {}, "False": {}, "and": {}, "or": {}, "not": {},
+}
+
+// tokenize converts source code into the normalised token stream described
+// in the file header. Line numbers are 1-based.
+func tokenize(src string) []token {
+	tokens := make([]token, 0, len(src)/4)
+	line := 1
+	i, n := 0, len(src)
+
+	for i < n {
+		ch := src[i]
+
+		switch {
+		case ch == '\n':
+			line++
+			i++
+
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+
+		// Block comment /* … */
+		case ch == '/' && i+1 < n && src[i+1] == '*':
+			i += 2
+			for i < n && !(src[i] == '*' && i+1 < n && src[i+1] == '/') {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+
+		// Line comments: // (C family) and # (Python, C preprocessor)
+		case (ch == '/' && i+1 < n && src[i+1] == '/') || ch == '#':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+
+		case ch == '"' || ch == '\'' || ch == '`':
+			startLine := line
+			i++
+			for i < n && src[i] != ch {
+				if src[i] == '\\' {
+					i++
+				}
+				if i < n && src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, token{text: "S", line: startLine})
+
+		case ch >= '0' && ch <= '9':
+			for i < n && (isIdentByte(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{text: "N", line: line})
+
+		case isIdentByte(ch):
+			j := i
+			for j < n && isIdentByte(src[j]) {
+				j++
+			}
+			word := src[i:j]
+			if _, ok := keywords[word]; ok {
+				tokens = append(tokens, token{text: word, line: line})
+			} else {
+				tokens = append(tokens, token{text: "I", line: line})
+			}
+			i = j
+
+		default:
+			tokens = append(tokens, token{text: string(ch), line: line})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isIdentByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') || c == '_'
+}
+
+// hashKgrams hashes every window of kgramSize consecutive tokens.
+func hashKgrams(tokens []token) []fingerprint {
+	if len(tokens) < kgramSize {
+		return nil
+	}
+
+	out := make([]fingerprint, 0, len(tokens)-kgramSize+1)
+	for i := 0; i+kgramSize <= len(tokens); i++ {
+		h := fnv.New64a()
+		var b strings.Builder
+		for _, t := range tokens[i : i+kgramSize] {
+			b.WriteString(t.text)
+			b.WriteByte(0)
+		}
+		h.Write([]byte(b.String()))
+		out = append(out, fingerprint{
+			hash:      int64(h.Sum64()),
+			startLine: tokens[i].line,
+			endLine:   tokens[i+kgramSize-1].line,
+		})
+	}
+	return out
+}
+
+// winnow selects the rightmost minimum hash in every window of windowSize
+// k-gram hashes, skipping consecutive windows that select the same k-gram.
+func winnow(kgrams []fingerprint) []fingerprint {
+	if len(kgrams) == 0 {
+		return nil
+	}
+	if len(kgrams) < windowSize {
+		minIdx := 0
+		for i := range kgrams {
+			if kgrams[i].hash <= kgrams[minIdx].hash {
+				minIdx = i
+			}
+		}
+		return []fingerprint{kgrams[minIdx]}
+	}
+
+	selected := []fingerprint{}
+	last := -1
+	for start := 0; start+windowSize <= len(kgrams); start++ {
+		minIdx := start
+		for i := start; i < start+windowSize; i++ {
+			if kgrams[i].hash <= kgrams[minIdx].hash {
+				minIdx = i
+			}
+		}
+		if minIdx != last {
+			selected = append(selected, kgrams[minIdx])
+			last = minIdx
+		}
+	}
+	return selected
+}
+
+// fingerprintSource runs the full tokenize → hash → winnow pipeline on a source file.
+func fingerprintSource(src string) []fingerprint {
+	return winnow(hashKgrams(tokenize(src)))
+}
@@ -0,0 +1,248 @@
+package plagiarism
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		texts []string
+		lines []int
+	}{
+		{
+			name:  "identifiers and numbers",
+			src:   "int x = 42;",
+			texts: []string{"I", "I", "=", "N", ";"},
+			lines: []int{1, 1, 1, 1, 1},
+		},
+		{
+			name:  "keywords and strings",
+			src:   `if (a) { return "s" }`,
+			texts: []string{"if", "(", "I", ")", "{", "return", "S", "}"},
+			lines: []int{1, 1, 1, 1, 1, 1, 1, 1},
+		},
+		{
+			name:  "floats and char literals",
+			src:   "x = 3.14 + 'c'",
+			texts: []string{"I", "=", "N", "+", "S"},
+			lines: []int{1, 1, 1, 1, 1},
+		},
+		{
+			name:  "comments are dropped and lines tracked",
+			src:   "a // c\n/* b\n */ b # x\nc",
+			texts: []string{"I", "I", "I"},
+			lines: []int{1, 3, 4},
+		},
+		{
+			name:  "multi-line string keeps its start line",
+			src:   "s = `one\ntwo`\nt",
+			texts: []string{"I", "=", "S", "I"},
+			lines: []int{1, 1, 1, 3},
+		},
+		{
+			name:  "escaped quote inside string",
+			src:   `"a\"b" c`,
+			texts: []string{"S", "I"},
+			lines: []int{1, 1},
+		},
+		{
+			name: "empty",
+			src:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var texts []string
+			var lines []int
+			for _, tok := range tokenize(tt.src) {
+				texts = append(texts, tok.text)
+				lines = append(lines, tok.line)
+			}
+			if !reflect.DeepEqual(texts, tt.texts) {
+				t.Errorf("texts = %q, want %q", texts, tt.texts)
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %v, want %v", lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestTokenizeIgnoresRenaming(t *testing.T) {
+	a := tokenize("for i := 0; i < n; i++ { total += xs[i] }")
+	b := tokenize("for j := 0; j < size; j++ { sum += values[j] }")
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("renamed programs tokenize differently:\n%v\n%v", a, b)
+	}
+}
+
+// tokens builds a token stream from space-separated texts, one per line.
+func tokens(s string) []token {
+	var out []token
+	for i, f := range strings.Fields(s) {
+		out = append(out, token{text: f, line: i + 1})
+	}
+	return out
+}
+
+func TestHashKgrams(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []token
+		want   int
+	}{
+		{"shorter than k", tokens("a b c"), 0},
+		{"exactly k", tokens("a b c d e f g h i j"), 1},
+		{"k plus two", tokens("a b c d e f g h i j k l"), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hashKgrams(tt.tokens)
+			if len(got) != tt.want {
+				t.Fatalf("got %d k-grams, want %d", len(got), tt.want)
+			}
+			for i, fp := range got {
+				if fp.startLine != i+1 || fp.endLine != i+kgramSize {
+					t.Errorf("k-gram %d spans lines %d-%d, want %d-%d", i, fp.startLine, fp.endLine, i+1, i+kgramSize)
+				}
+			}
+		})
+	}
+}
+
+func TestHashKgramsContent(t *testing.T) {
+	// The same k-gram hashes identically wherever it occurs; a different one
+	// (or the same texts joined differently) does not.
+	got := hashKgrams(tokens("a b c d e f g h i j x a b c d e f g h i j"))
+	if got[0].hash != got[len(got)-1].hash {
+		t.Error("identical k-grams hash differently")
+	}
+	if got[0].hash == got[1].hash {
+		t.Error("different k-grams hash identically")
+	}
+
+	ab := hashKgrams(tokens("ab c d e f g h i j k"))
+	a := hashKgrams(tokens("a bc d e f g h i j k"))
+	if ab[0].hash == a[0].hash {
+		t.Error("token boundaries do not affect the hash")
+	}
+}
+
+// prints builds fingerprints with the given hashes; startLine is the index
+// so that the selected positions can be checked.
+func prints(hashes ...int64) []fingerprint {
+	out := make([]fingerprint, len(hashes))
+	for i, h := range hashes {
+		out[i] = fingerprint{hash: h, startLine: i, endLine: i}
+	}
+	return out
+}
+
+func TestWinnow(t *testing.T) {
+	tests := []struct {
+		name   string
+		kgrams []fingerprint
+		want   []int // selected positions
+	}{
+		{"empty", nil, nil},
+		{"fewer than a window", prints(4, 2, 2), []int{2}},
+		{"one window", prints(5, 3, 4, 3, 6), []int{3}},
+		{"repeated minimum selected once", prints(5, 3, 4, 3, 6, 2, 7, 8), []int{3, 5}},
+		{"rightmost of equal minima", prints(1, 1, 1, 1, 1, 1), []int{4, 5}},
+		{"increasing", prints(1, 2, 3, 4, 5, 6, 7), []int{0, 1, 2}},
+		{"decreasing", prints(7, 6, 5, 4, 3, 2, 1), []int{4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, fp := range winnow(tt.kgrams) {
+				got = append(got, fp.startLine)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWinnowGuarantee(t *testing.T) {
+	// A shared run of kgramSize+windowSize-1 tokens must yield a shared
+	// fingerprint however different the surrounding code is.
+	shared := "for ( i = 0 ; i < n ; i ++ ) { s += a [ i ] ; }"
+	a := fingerprintSource("x = 1\n" + shared + "\nreturn y")
+	b := fingerprintSource("while (true) { break }\n" + shared + "\nprint(z, w)")
+
+	if n, _ := compareFingerprints(a, b); n == 0 {
+		t.Error("no shared fingerprint for a shared run longer than the guarantee threshold")
+	}
+}
+
+func TestCompareFingerprints(t *testing.T) {
+	fp := func(hash int64, start, end int) fingerprint {
+		return fingerprint{hash: hash, startLine: start, endLine: end}
+	}
+
+	tests := []struct {
+		name    string
+		a, b    []fingerprint
+		shared  int
+		regions []MatchRegion
+	}{
+		{
+			name:    "no overlap",
+			a:       []fingerprint{fp(1, 1, 2)},
+			b:       []fingerprint{fp(2, 1, 2)},
+			shared:  0,
+			regions: []MatchRegion{},
+		},
+		{
+			name:   "adjacent spans merge, distant ones do not",
+			a:      []fingerprint{fp(1, 1, 2), fp(2, 2, 3), fp(3, 10, 11)},
+			b:      []fingerprint{fp(1, 5, 6), fp(2, 6, 7), fp(3, 20, 21), fp(9, 1, 1)},
+			shared: 3,
+			regions: []MatchRegion{
+				{AStart: 1, AEnd: 3, BStart: 5, BEnd: 7},
+				{AStart: 10, AEnd: 11, BStart: 20, BEnd: 21},
+			},
+		},
+		{
+			name:   "B moving backwards starts a new region",
+			a:      []fingerprint{fp(1, 1, 1), fp(2, 2, 2)},
+			b:      []fingerprint{fp(1, 5, 5), fp(2, 1, 1)},
+			shared: 2,
+			regions: []MatchRegion{
+				{AStart: 1, AEnd: 1, BStart: 5, BEnd: 5},
+				{AStart: 2, AEnd: 2, BStart: 1, BEnd: 1},
+			},
+		},
+		{
+			name:   "repeated hash counted once",
+			a:      []fingerprint{fp(1, 1, 1), fp(1, 8, 8)},
+			b:      []fingerprint{fp(1, 3, 3)},
+			shared: 1,
+			regions: []MatchRegion{
+				{AStart: 1, AEnd: 1, BStart: 3, BEnd: 3},
+				{AStart: 8, AEnd: 8, BStart: 3, BEnd: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared, regions := compareFingerprints(tt.a, tt.b)
+			if shared != tt.shared {
+				t.Errorf("shared = %d, want %d", shared, tt.shared)
+			}
+			if !reflect.DeepEqual(regions, tt.regions) {
+				t.Errorf("regions = %+v, want %+v", regions, tt.regions)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package plagiarism
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MatchResponse struct {
+	ID          uuid.UUID     `json:"id"`
+	SubmissionA uuid.UUID     `json:"submission_a"`
+	SubmissionB uuid.UUID     `json:"submission_b"`
+	UserA       uuid.UUID     `json:"user_a"`
+	UserB       uuid.UUID     `json:"user_b"`
+	SharedCount int           `json:"shared_fingerprints"`
+	CoverageA   float64       `json:"coverage_a"`
+	CoverageB   float64       `json:"coverage_b"`
+	Regions     []MatchRegion `json:"regions"`
+	CreatedAt   string        `json:"created_at"`
+}
+
+// hidePrivate drops matches whose other side is a private submission of
+// another user. CheckSubmission no longer records such matches; this covers
+// rows stored before it did.
+func hidePrivate(userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`NOT EXISTS (
+			SELECT 1 FROM code_submissions cs
+			WHERE cs.id IN (submission_a, submission_b)
+			  AND cs.visibility = 'private' AND cs.user_id <> ?
+		)`, userID)
+	}
+}
+
+// GetMyMatches handles GET /api/plagiarism/matches.
+// Returns every near-duplicate match that involves one of the caller's
+// submissions, on either side of the pair.
+func GetMyMatches(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var matches []SubmissionMatch
+		if err := db.Where("user_a = ? OR user_b = ?", userID, userID).
+			Scopes(hidePrivate(userID)).
+			Order("created_at DESC").
+			Find(&matches).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load matches"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponses(matches))
+	}
+}
+
+// GetSubmissionMatches handles GET /api/submissions/:id/matches.
+// Only the owner of the submission may see its matches.
+func GetSubmissionMatches(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		submissionID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid submission id"})
+			return
+		}
+
+		var owner struct{ UserID uuid.UUID }
+		if err := db.Table("code_submissions").
+			Select("user_id").
+			Where("id = ?", submissionID).
+			Take(&owner).Error; err != nil || owner.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+			return
+		}
+
+		var matches []SubmissionMatch
+		if err := db.Where("submission_a = ? OR submission_b = ?", submissionID, submissionID).
+			Scopes(hidePrivate(userID)).
+			Order("created_at DESC").
+			Find(&matches).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load matches"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponses(matches))
+	}
+}
+
+// ListAllMatches returns every recorded match, highest coverage first.
+// It is intended for moderation and must only be mounted behind
+// auth.RequireRole(user.RoleModerator). Supports ?limit= and ?offset=.
+func ListAllMatches(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		if offset < 0 {
+			offset = 0
+		}
+
+		var matches []SubmissionMatch
+		if err := db.Order("GREATEST(coverage_a, coverage_b) DESC").
+			Limit(limit).
+			Offset(offset).
+			Find(&matches).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load matches"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponses(matches))
+	}
+}
+
+func toResponses(matches []SubmissionMatch) []MatchResponse {
+	out := make([]MatchResponse, 0, len(matches))
+	for _, m := range matches {
+		regions := []MatchRegion{}
+		if err := json.Unmarshal([]byte(m.Regions), &regions); err != nil {
+			log.Printf("match %s has corrupt regions: %v\n", m.ID, err)
+		}
+
+		out = append(out, MatchResponse{
+			ID:          m.ID,
+			SubmissionA: m.SubmissionA,
+			SubmissionB: m.SubmissionB,
+			UserA:       m.UserA,
+			UserB:       m.UserB,
+			SharedCount: m.SharedCount,
+			CoverageA:   m.CoverageA,
+			CoverageB:   m.CoverageB,
+			Regions:     regions,
+			CreatedAt:   m.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return out
+}
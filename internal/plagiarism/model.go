@@ -0,0 +1,41 @@
+package plagiarism
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubmissionFingerprint is one winnowed k-gram hash selected from a
+// submission, together with the source lines the k-gram spans.
+type SubmissionFingerprint struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SubmissionID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Hash         int64     `gorm:"not null;index"`
+	StartLine    int       `gorm:"not null"`
+	EndLine      int       `gorm:"not null"`
+}
+
+// SubmissionMatch records a near-duplicate pair between two submissions
+// owned by different users. SubmissionA is always the submission whose
+// analysis produced the match; SubmissionB is the earlier one it resembles.
+type SubmissionMatch struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SubmissionA uuid.UUID `gorm:"type:uuid;not null;index"`
+	SubmissionB uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserA       uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserB       uuid.UUID `gorm:"type:uuid;not null;index"`
+	SharedCount int       `gorm:"not null"`
+	CoverageA   float64   // fraction of A's fingerprints also found in B
+	CoverageB   float64   // fraction of B's fingerprints also found in A
+	Regions     string    `gorm:"type:text"` // JSON-encoded []MatchRegion
+	CreatedAt   time.Time
+}
+
+// MatchRegion is a pair of aligned line ranges that share fingerprints.
+type MatchRegion struct {
+	AStart int `json:"a_start"`
+	AEnd   int `json:"a_end"`
+	BStart int `json:"b_start"`
+	BEnd   int `json:"b_end"`
+}
@@ -0,0 +1,9 @@
+package problem
+
+type CreateProblemRequest struct {
+	Title              string   `json:"title" binding:"required"`
+	Statement          string   `json:"statement" binding:"required"`
+	Tags               []string `json:"tags"`
+	Difficulty         string   `json:"difficulty" binding:"required,oneof=easy medium hard"`
+	ExpectedComplexity string   `json:"expected_complexity"`
+}
@@ -0,0 +1,238 @@
+package problem
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProblemResponse struct {
+	ID                 uuid.UUID `json:"id"`
+	Title              string    `json:"title"`
+	Statement          string    `json:"statement"`
+	Tags               []string  `json:"tags"`
+	Difficulty         string    `json:"difficulty"`
+	ExpectedComplexity string    `json:"expected_complexity"`
+	CreatedAt          string    `json:"created_at"`
+}
+
+// ApproachSubmission is one public submission inside an ApproachGroup.
+type ApproachSubmission struct {
+	SubmissionID uuid.UUID `json:"submission_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Language     string    `json:"language"`
+	CreatedAt    string    `json:"created_at"`
+}
+
+// ApproachGroup collects submissions to the same problem that were analysed
+// as the same set of patterns with the same time complexity.
+type ApproachGroup struct {
+	TimeComplexity  string               `json:"time_complexity"`
+	SpaceComplexity string               `json:"space_complexity"`
+	Patterns        []string             `json:"patterns"`
+	MeetsExpected   bool                 `json:"meets_expected"`
+	Submissions     []ApproachSubmission `json:"submissions"`
+}
+
+// CreateProblem handles POST /api/problems.
+func CreateProblem(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var req CreateProblemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		p := Problem{
+			ID:                 uuid.New(),
+			Title:              strings.TrimSpace(req.Title),
+			Statement:          req.Statement,
+			Tags:               joinTags(req.Tags),
+			Difficulty:         req.Difficulty,
+			ExpectedComplexity: strings.TrimSpace(req.ExpectedComplexity),
+			CreatedBy:          userID,
+			CreatedAt:          time.Now(),
+		}
+
+		if err := db.Create(&p).Error; err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "problem with this title already exists"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, toResponse(p))
+	}
+}
+
+// ListProblems handles GET /api/problems.
+// Optional filters: ?tag= and ?difficulty=.
+func ListProblems(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&Problem{})
+
+		if d := c.Query("difficulty"); d != "" {
+			query = query.Where("difficulty = ?", d)
+		}
+		if t := strings.ToLower(strings.TrimSpace(c.Query("tag"))); t != "" {
+			query = query.Where("? = ANY(STRING_TO_ARRAY(tags, ','))", t)
+		}
+
+		var problems []Problem
+		if err := query.Order("created_at DESC").Find(&problems).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load problems"})
+			return
+		}
+
+		out := make([]ProblemResponse, 0, len(problems))
+		for _, p := range problems {
+			out = append(out, toResponse(p))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// GetProblem handles GET /api/problems/:id.
+func GetProblem(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p Problem
+		if err := db.Where("id = ?", c.Param("id")).First(&p).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toResponse(p))
+	}
+}
+
+// GetApproaches handles GET /api/problems/:id/approaches.
+// Lists every public, analysed submission to the problem grouped by its
+// detected pattern set and time complexity, largest group first.
+func GetApproaches(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p Problem
+		if err := db.Where("id = ?", c.Param("id")).First(&p).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+			return
+		}
+
+		// A re-analysed submission has several analyses; only the latest
+		// counts, so each submission yields exactly one row.
+		rows, err := db.Raw(`
+			WITH latest AS (
+				SELECT DISTINCT ON (submission_id) submission_id, time_complexity, space_complexity
+				FROM code_analyses
+				ORDER BY submission_id, created_at DESC
+			)
+			SELECT cs.id, cs.user_id, cs.language, cs.created_at,
+			       ca.time_complexity, ca.space_complexity,
+			       COALESCE(STRING_AGG(ap.name, ',' ORDER BY ap.name), '')
+			FROM code_submissions cs
+			JOIN latest ca ON ca.submission_id = cs.id
+			LEFT JOIN submission_patterns sp ON sp.submission_id = cs.id
+			LEFT JOIN algorithm_patterns ap ON ap.id = sp.pattern_id
+			WHERE cs.problem_id = ? AND cs.visibility = 'public'
+			GROUP BY cs.id, cs.user_id, cs.language, cs.created_at,
+			         ca.time_complexity, ca.space_complexity
+			ORDER BY cs.created_at
+		`, p.ID).Rows()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load approaches"})
+			return
+		}
+		defer rows.Close()
+
+		groups := map[string]*ApproachGroup{}
+		order := []string{}
+
+		for rows.Next() {
+			var (
+				sub           ApproachSubmission
+				createdAt     time.Time
+				timeC, spaceC string
+				patternList   string
+			)
+			if err := rows.Scan(&sub.SubmissionID, &sub.UserID, &sub.Language, &createdAt,
+				&timeC, &spaceC, &patternList); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load approaches"})
+				return
+			}
+			sub.CreatedAt = createdAt.Format("2006-01-02")
+
+			key := timeC + "|" + patternList
+			g, ok := groups[key]
+			if !ok {
+				g = &ApproachGroup{
+					TimeComplexity:  timeC,
+					SpaceComplexity: spaceC,
+					Patterns:        splitTags(patternList),
+					MeetsExpected:   p.ExpectedComplexity == "" || p.ExpectedComplexity == timeC,
+				}
+				groups[key] = g
+				order = append(order, key)
+			}
+			g.Submissions = append(g.Submissions, sub)
+		}
+
+		result := make([]ApproachGroup, 0, len(order))
+		for _, key := range order {
+			result = append(result, *groups[key])
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return len(result[i].Submissions) > len(result[j].Submissions)
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"problem":    toResponse(p),
+			"approaches": result,
+		})
+	}
+}
+
+func toResponse(p Problem) ProblemResponse {
+	return ProblemResponse{
+		ID:                 p.ID,
+		Title:              p.Title,
+		Statement:          p.Statement,
+		Tags:               splitTags(p.Tags),
+		Difficulty:         p.Difficulty,
+		ExpectedComplexity: p.ExpectedComplexity,
+		CreatedAt:          p.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+// joinTags normalises tags to trimmed lower-case and stores them as a
+// comma-separated list without duplicates.
+func joinTags(tags []string) string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		t = strings.ReplaceAll(t, ",", " ")
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return strings.Join(out, ",")
+}
+
+func splitTags(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// Exists reports whether a problem with the given ID is in the catalog.
+func Exists(db *gorm.DB, id uuid.UUID) bool {
+	var count int64
+	db.Model(&Problem{}).Where("id = ?", id).Count(&count)
+	return count > 0
+}
@@ -0,0 +1,18 @@
+package problem
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Problem struct {
+	ID                 uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Title              string    `gorm:"unique;not null"`
+	Statement          string    `gorm:"type:text;not null"`
+	Tags               string    `gorm:"default:''"` // comma-separated, lower-case
+	Difficulty         string    `gorm:"not null;index"`
+	ExpectedComplexity string    `gorm:"default:''"` // optional Big-O target, e.g. "O(n log n)"
+	CreatedBy          uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt          time.Time
+}
@@ -0,0 +1,55 @@
+package review
+
+import "strings"
+
+// extractLines returns lines [start, end] (1-based, inclusive) of src joined
+// with "\n". ok is false when the range falls outside the source.
+func extractLines(src string, start, end int) (string, bool) {
+	lines := strings.Split(src, "\n")
+	if start < 1 || end < start || end > len(lines) {
+		return "", false
+	}
+	return strings.Join(lines[start-1:end], "\n"), true
+}
+
+// relocate finds where an anchored block of lines now lives in src.
+// Lines are compared with surrounding whitespace trimmed so re-indentation
+// does not lose the anchor. When the block occurs several times the
+// occurrence closest to the original start line wins. ok is false if the
+// block no longer exists.
+func relocate(src, anchor string, origStart int) (start, end int, ok bool) {
+	want := strings.Split(anchor, "\n")
+	for i := range want {
+		want[i] = strings.TrimSpace(want[i])
+	}
+
+	lines := strings.Split(src, "\n")
+	best := -1
+	for i := 0; i+len(want) <= len(lines); i++ {
+		matched := true
+		for j, w := range want {
+			if strings.TrimSpace(lines[i+j]) != w {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if best < 0 || abs(i+1-origStart) < abs(best+1-origStart) {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		return origStart, origStart + len(want) - 1, false
+	}
+	return best + 1, best + len(want), true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,100 @@
+package review
+
+import "testing"
+
+func TestExtractLines(t *testing.T) {
+	src := "a\nb\nc\nd"
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       string
+		ok         bool
+	}{
+		{"single line", 2, 2, "b", true},
+		{"range", 2, 4, "b\nc\nd", true},
+		{"whole source", 1, 4, src, true},
+		{"start before first line", 0, 1, "", false},
+		{"end before start", 3, 2, "", false},
+		{"end past last line", 3, 5, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := extractLines(src, tt.start, tt.end)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("extractLines(%d, %d) = %q, %v; want %q, %v", tt.start, tt.end, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		anchor     string
+		origStart  int
+		start, end int
+		ok         bool
+	}{
+		{
+			name:      "unchanged",
+			src:       "a\nb\nc\nd",
+			anchor:    "b\nc",
+			origStart: 2,
+			start:     2, end: 3, ok: true,
+		},
+		{
+			name:      "moved down by inserted lines",
+			src:       "x\ny\na\nb\nc\nd",
+			anchor:    "b\nc",
+			origStart: 2,
+			start:     4, end: 5, ok: true,
+		},
+		{
+			name:      "re-indented",
+			src:       "func f() {\n\tif x {\n\t\treturn\n\t}\n}",
+			anchor:    "if x {\n    return",
+			origStart: 1,
+			start:     2, end: 3, ok: true,
+		},
+		{
+			name:      "closest of several occurrences",
+			src:       "b\nc\nx\nx\nx\nb\nc\nx",
+			anchor:    "b\nc",
+			origStart: 5,
+			start:     6, end: 7, ok: true,
+		},
+		{
+			name:      "tie keeps the earlier occurrence",
+			src:       "b\nx\nx\nx\nb",
+			anchor:    "b",
+			origStart: 3,
+			start:     1, end: 1, ok: true,
+		},
+		{
+			name:      "block removed",
+			src:       "a\nb\nd",
+			anchor:    "b\nc",
+			origStart: 2,
+			start:     2, end: 3, ok: false,
+		},
+		{
+			name:      "block longer than source",
+			src:       "a",
+			anchor:    "a\nb",
+			origStart: 1,
+			start:     1, end: 2, ok: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := relocate(tt.src, tt.anchor, tt.origStart)
+			if start != tt.start || end != tt.end || ok != tt.ok {
+				t.Errorf("relocate = %d, %d, %v; want %d, %d, %v", start, end, ok, tt.start, tt.end, tt.ok)
+			}
+		})
+	}
+}
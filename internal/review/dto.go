@@ -0,0 +1,15 @@
+package review
+
+type CreateThreadRequest struct {
+	StartLine int    `json:"start_line" binding:"required,min=1"`
+	EndLine   int    `json:"end_line" binding:"required,min=1"`
+	Body      string `json:"body" binding:"required"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type ResolveRequest struct {
+	Resolved *bool `json:"resolved" binding:"required"`
+}
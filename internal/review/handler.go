@@ -0,0 +1,195 @@
+package review
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetThreads handles GET /api/submissions/:id/reviews.
+func GetThreads(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		sub, ok := viewableSubmission(c, db, userID)
+		if !ok {
+			return
+		}
+
+		threads, err := threadsFor(db, sub)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load review threads"})
+			return
+		}
+
+		c.JSON(http.StatusOK, threads)
+	}
+}
+
+// CreateThread handles POST /api/submissions/:id/reviews.
+// Opens a thread on a line range with its first comment.
+func CreateThread(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var req CreateThreadRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sub, ok := viewableSubmission(c, db, userID)
+		if !ok {
+			return
+		}
+
+		anchor, ok := extractLines(sub.SourceCode, req.StartLine, req.EndLine)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "line range is outside the submission"})
+			return
+		}
+
+		now := time.Now()
+		thread := ReviewThread{
+			ID:           uuid.New(),
+			SubmissionID: sub.ID,
+			AuthorID:     userID,
+			StartLine:    req.StartLine,
+			EndLine:      req.EndLine,
+			AnchorText:   anchor,
+			CreatedAt:    now,
+		}
+		comment := ReviewComment{
+			ID:        uuid.New(),
+			ThreadID:  thread.ID,
+			AuthorID:  userID,
+			Body:      req.Body,
+			CreatedAt: now,
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&thread).Error; err != nil {
+				return err
+			}
+			return tx.Create(&comment).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create review thread"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"thread_id":  thread.ID,
+			"comment_id": comment.ID,
+		})
+	}
+}
+
+// AddComment handles POST /api/reviews/:thread_id/comments.
+func AddComment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var req CreateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		thread, _, ok := viewableThread(c, db, userID)
+		if !ok {
+			return
+		}
+
+		comment := ReviewComment{
+			ID:        uuid.New(),
+			ThreadID:  thread.ID,
+			AuthorID:  userID,
+			Body:      req.Body,
+			CreatedAt: time.Now(),
+		}
+		if err := db.Create(&comment).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add comment"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"comment_id": comment.ID})
+	}
+}
+
+// ResolveThread handles PATCH /api/reviews/:thread_id.
+// The submission owner and the thread author may resolve or reopen it.
+func ResolveThread(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(uuid.UUID)
+
+		var req ResolveRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		thread, sub, ok := viewableThread(c, db, userID)
+		if !ok {
+			return
+		}
+		if userID != sub.UserID && userID != thread.AuthorID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the submission owner or thread author can resolve"})
+			return
+		}
+
+		if *req.Resolved {
+			now := time.Now()
+			thread.Resolved = true
+			thread.ResolvedBy = &userID
+			thread.ResolvedAt = &now
+		} else {
+			thread.Resolved = false
+			thread.ResolvedBy = nil
+			thread.ResolvedAt = nil
+		}
+
+		if err := db.Save(thread).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update thread"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"thread_id": thread.ID, "resolved": thread.Resolved})
+	}
+}
+
+// viewableSubmission loads the :id submission and writes a 404 unless the
+// caller may see it. Private submissions are reported as missing rather
+// than forbidden so their existence is not leaked.
+func viewableSubmission(c *gin.Context, db *gorm.DB, userID uuid.UUID) (*submissionInfo, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid submission id"})
+		return nil, false
+	}
+
+	sub, err := loadSubmission(db, id)
+	if err != nil || !sub.canView(userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+		return nil, false
+	}
+	return sub, true
+}
+
+func viewableThread(c *gin.Context, db *gorm.DB, userID uuid.UUID) (*ReviewThread, *submissionInfo, bool) {
+	var thread ReviewThread
+	if err := db.Where("id = ?", c.Param("thread_id")).First(&thread).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return nil, nil, false
+	}
+
+	sub, err := loadSubmission(db, thread.SubmissionID)
+	if err != nil || !sub.canView(userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		return nil, nil, false
+	}
+	return &thread, sub, true
+}
@@ -0,0 +1,31 @@
+package review
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewThread is a discussion anchored to a line range of a submission.
+// AnchorText keeps a copy of the anchored lines so the thread can be
+// relocated on later revisions of the same file.
+type ReviewThread struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	SubmissionID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	AuthorID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	StartLine    int        `gorm:"not null"`
+	EndLine      int        `gorm:"not null"`
+	AnchorText   string     `gorm:"type:text;not null"`
+	Resolved     bool       `gorm:"not null;default:false"`
+	ResolvedBy   *uuid.UUID `gorm:"type:uuid"`
+	ResolvedAt   *time.Time
+	CreatedAt    time.Time
+}
+
+type ReviewComment struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ThreadID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	AuthorID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	Body      string    `gorm:"type:text;not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,163 @@
+package review
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentResponse struct {
+	ID        uuid.UUID `json:"id"`
+	AuthorID  uuid.UUID `json:"author_id"`
+	Body      string    `json:"body"`
+	CreatedAt string    `json:"created_at"`
+}
+
+// ThreadResponse is a thread as seen on one particular submission. When the
+// thread was opened on an earlier revision, StartLine/EndLine are the
+// relocated range and Outdated reports that the anchored code is gone.
+type ThreadResponse struct {
+	ID           uuid.UUID         `json:"id"`
+	SubmissionID uuid.UUID         `json:"submission_id"`
+	AuthorID     uuid.UUID         `json:"author_id"`
+	StartLine    int               `json:"start_line"`
+	EndLine      int               `json:"end_line"`
+	Outdated     bool              `json:"outdated"`
+	Resolved     bool              `json:"resolved"`
+	ResolvedBy   *uuid.UUID        `json:"resolved_by,omitempty"`
+	ResolvedAt   string            `json:"resolved_at,omitempty"`
+	Comments     []CommentResponse `json:"comments"`
+	CreatedAt    string            `json:"created_at"`
+}
+
+// submissionInfo is the subset of code_submissions needed for review
+// authorization and anchoring.
+type submissionInfo struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	SourceCode string
+	Visibility string
+	SourceRepo string
+	SourcePath string
+	CreatedAt  time.Time
+}
+
+func loadSubmission(db *gorm.DB, id uuid.UUID) (*submissionInfo, error) {
+	var s submissionInfo
+	err := db.Table("code_submissions").
+		Select("id, user_id, source_code, visibility, source_repo, source_path, created_at").
+		Where("id = ?", id).
+		Take(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// canView reports whether userID may see (and therefore review) a
+// submission: public submissions are visible to everyone, private ones
+// only to their owner.
+func (s *submissionInfo) canView(userID uuid.UUID) bool {
+	return s.Visibility != "private" || s.UserID == userID
+}
+
+// revisionIDs returns the submission plus every earlier revision of the
+// same file. Only git-imported submissions have revisions; they share the
+// owner, repository and path.
+func revisionIDs(db *gorm.DB, s *submissionInfo) ([]uuid.UUID, error) {
+	if s.SourcePath == "" {
+		return []uuid.UUID{s.ID}, nil
+	}
+
+	var ids []uuid.UUID
+	err := db.Table("code_submissions").
+		Where("user_id = ? AND source_repo = ? AND source_path = ? AND created_at <= ?",
+			s.UserID, s.SourceRepo, s.SourcePath, s.CreatedAt).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ThreadsForSubmission returns all review threads visible on a submission,
+// including threads carried forward from earlier revisions, ordered by
+// line. It does not check authorization; callers must do so.
+func ThreadsForSubmission(db *gorm.DB, submissionID uuid.UUID) ([]ThreadResponse, error) {
+	sub, err := loadSubmission(db, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	return threadsFor(db, sub)
+}
+
+func threadsFor(db *gorm.DB, sub *submissionInfo) ([]ThreadResponse, error) {
+	ids, err := revisionIDs(db, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	var threads []ReviewThread
+	if err := db.Where("submission_id IN ?", ids).
+		Order("start_line, created_at").
+		Find(&threads).Error; err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return []ThreadResponse{}, nil
+	}
+
+	threadIDs := make([]uuid.UUID, len(threads))
+	for i, t := range threads {
+		threadIDs[i] = t.ID
+	}
+
+	var comments []ReviewComment
+	if err := db.Where("thread_id IN ?", threadIDs).
+		Order("created_at").
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	byThread := map[uuid.UUID][]CommentResponse{}
+	for _, cm := range comments {
+		byThread[cm.ThreadID] = append(byThread[cm.ThreadID], CommentResponse{
+			ID:        cm.ID,
+			AuthorID:  cm.AuthorID,
+			Body:      cm.Body,
+			CreatedAt: cm.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	out := make([]ThreadResponse, 0, len(threads))
+	for _, t := range threads {
+		start, end, outdated := t.StartLine, t.EndLine, false
+		if t.SubmissionID != sub.ID {
+			var found bool
+			start, end, found = relocate(sub.SourceCode, t.AnchorText, t.StartLine)
+			outdated = !found
+		}
+
+		resp := ThreadResponse{
+			ID:           t.ID,
+			SubmissionID: t.SubmissionID,
+			AuthorID:     t.AuthorID,
+			StartLine:    start,
+			EndLine:      end,
+			Outdated:     outdated,
+			Resolved:     t.Resolved,
+			ResolvedBy:   t.ResolvedBy,
+			Comments:     byThread[t.ID],
+			CreatedAt:    t.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if t.ResolvedAt != nil {
+			resp.ResolvedAt = t.ResolvedAt.Format("2006-01-02 15:04:05")
+		}
+		if resp.Comments == nil {
+			resp.Comments = []CommentResponse{}
+		}
+		out = append(out, resp)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartLine < out[j].StartLine })
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errLastAdmin = errors.New("cannot remove the last admin")
+
+type AdminUserResponse struct {
+	ID        uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt string    `json:"created_at"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func toAdminResponse(u User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+// ListUsers handles GET /api/admin/users.
+// Supports ?role=, ?limit= (default 50, at most 200) and ?offset=.
+func ListUsers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+			return
+		}
+
+		query := db.Order("created_at, id").Limit(limit).Offset(offset)
+		if role := c.Query("role"); role != "" {
+			if !ValidRole(role) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role"})
+				return
+			}
+			query = query.Where("role = ?", role)
+		}
+
+		var users []User
+		if err := query.Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load users"})
+			return
+		}
+
+		out := make([]AdminUserResponse, 0, len(users))
+		for _, u := range users {
+			out = append(out, toAdminResponse(u))
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
+
+// SetRole handles PUT /api/admin/users/:id/role.
+// The last admin cannot be demoted. Access tokens carry the role, so the
+// change takes effect when the user's current access token expires.
+func SetRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+
+		var req SetRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !ValidRole(req.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role must be user, moderator or admin"})
+			return
+		}
+
+		var u User
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Locking every admin row, in a fixed order, serialises
+			// concurrent demotions so they cannot both pass the last-admin
+			// check.
+			var admins []uuid.UUID
+			if err := tx.Model(&User{}).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("role = ?", RoleAdmin).
+				Order("id").
+				Pluck("id", &admins).Error; err != nil {
+				return err
+			}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", id).
+				First(&u).Error; err != nil {
+				return err
+			}
+			if u.Role == RoleAdmin && req.Role != RoleAdmin && len(admins) <= 1 {
+				return errLastAdmin
+			}
+			u.Role = req.Role
+			return tx.Model(&u).Update("role", req.Role).Error
+		})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		case errors.Is(err, errLastAdmin):
+			c.JSON(http.StatusConflict, gin.H{"error": errLastAdmin.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+			return
+		}
+
+		c.JSON(http.StatusOK, toAdminResponse(u))
+	}
+}
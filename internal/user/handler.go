@@ -10,12 +10,14 @@ import (
 
 // ProfileResponse is the shape returned by GET /api/me and GET /api/profile.
 type ProfileResponse struct {
-	ID        uuid.UUID `json:"user_id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	AvatarURL string    `json:"avatar_url"`
-	Bio       string    `json:"bio"`
-	CreatedAt string    `json:"created_at"`
+	ID            uuid.UUID `json:"user_id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	AvatarURL     string    `json:"avatar_url"`
+	Bio           string    `json:"bio"`
+	Role          string    `json:"role"`
+	CreatedAt     string    `json:"created_at"`
 }
 
 // UpdateProfileRequest holds the fields a user is allowed to change.
@@ -38,12 +40,14 @@ func GetProfile(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		c.JSON(http.StatusOK, ProfileResponse{
-			ID:        u.ID,
-			Username:  u.Username,
-			Email:     u.Email,
-			AvatarURL: u.AvatarURL,
-			Bio:       u.Bio,
-			CreatedAt: u.CreatedAt.Format("2006-01-02"),
+			ID:            u.ID,
+			Username:      u.Username,
+			Email:         u.Email,
+			EmailVerified: u.EmailVerified,
+			AvatarURL:     u.AvatarURL,
+			Bio:           u.Bio,
+			Role:          u.Role,
+			CreatedAt:     u.CreatedAt.Format("2006-01-02"),
 		})
 	}
 }
@@ -81,12 +85,14 @@ func UpdateProfile(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		c.JSON(http.StatusOK, ProfileResponse{
-			ID:        u.ID,
-			Username:  u.Username,
-			Email:     u.Email,
-			AvatarURL: u.AvatarURL,
-			Bio:       u.Bio,
-			CreatedAt: u.CreatedAt.Format("2006-01-02"),
+			ID:            u.ID,
+			Username:      u.Username,
+			Email:         u.Email,
+			EmailVerified: u.EmailVerified,
+			AvatarURL:     u.AvatarURL,
+			Bio:           u.Bio,
+			Role:          u.Role,
+			CreatedAt:     u.CreatedAt.Format("2006-01-02"),
 		})
 	}
 }
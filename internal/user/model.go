@@ -7,11 +7,13 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
-	Username     string    `gorm:"unique;not null"`
-	Email        string    `gorm:"unique;not null"`
-	PasswordHash string    `gorm:"not null"`
-	AvatarURL    string    `gorm:"default:''"` 
-	Bio          string    `gorm:"default:''"` 
-	CreatedAt    time.Time
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Username      string    `gorm:"unique;not null"`
+	Email         string    `gorm:"unique;not null"`
+	PasswordHash  string    `gorm:"not null"`
+	AvatarURL     string    `gorm:"default:''"`
+	Bio           string    `gorm:"default:''"`
+	Role          string    `gorm:"not null;default:'user'"`
+	EmailVerified bool      `gorm:"not null;default:false"`
+	CreatedAt     time.Time
 }
@@ -0,0 +1,28 @@
+package user
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// roleRanks orders roles by privilege; each role includes the ones below it.
+var roleRanks = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// ValidRole reports whether role is a known role.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole reports whether role grants at least the privileges of required.
+// Unknown or empty roles count as RoleUser; an unknown required role is
+// never granted.
+func HasRole(role, required string) bool {
+	rank, ok := roleRanks[required]
+	return ok && roleRanks[role] >= rank
+}